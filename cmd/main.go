@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"rockbingo/internal/api"
 	"rockbingo/internal/db"
-	"rockbingo/internal/game"
+	"rockbingo/internal/payment"
+	"rockbingo/internal/reconcile"
+	"rockbingo/internal/scheduler"
 	"rockbingo/internal/telegrambot"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -25,6 +31,9 @@ func main() {
 	if err := db.MigrateDB(dbURL); err != nil {
 		log.Fatalf("Migration error: %v", err)
 	}
+	db.SetRoomConfigFromEnv()
+	db.SetReferralConfigFromEnv()
+	db.SetLimitConfigFromEnv()
 
 	database, err := sqlx.Connect("postgres", dbURL)
 	if err != nil {
@@ -32,9 +41,6 @@ func main() {
 	}
 	defer database.Close()
 
-	// Start stuck room recovery goroutine
-	game.StartStuckRoomRecovery(database.DB) // Use .DB to get *sql.DB from sqlx.DB
-
 	// Initialize stores
 	userStore := db.NewUserStore(database)
 	roomStore := db.NewRoomStore(database)
@@ -42,6 +48,32 @@ func main() {
 	cardStore := db.NewCardStore(database)
 	walletStore := db.NewWalletStore(database)
 	auditStore := db.NewAuditStore(database)
+	idempotencyStore := db.NewIdempotencyStore(database)
+	ledgerStore := db.NewLedgerStore(database)
+	tierStore := db.NewTierStore(database)
+	withdrawalStore := db.NewWithdrawalStore(database)
+	promoStore := db.NewPromoStore(database)
+	referralStore := db.NewReferralStore(database)
+	limitStore := db.NewLimitStore(database)
+	patternStore := db.NewPatternStore(database)
+	serviceNonceStore := db.NewServiceNonceStore(database)
+
+	// Bootstrap admins so roles can be granted through the API afterwards
+	var adminTelegramIDs []int64
+	for _, idStr := range strings.Split(os.Getenv("ADMIN_TELEGRAM_IDS"), ",") {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+		telegramID, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid ADMIN_TELEGRAM_IDS entry %q: %v", idStr, err)
+		}
+		if err := userStore.GrantRoleByTelegram(context.Background(), telegramID, db.RoleAdmin); err != nil {
+			log.Fatalf("Failed to grant admin role to telegram user %d: %v", telegramID, err)
+		}
+		adminTelegramIDs = append(adminTelegramIDs, telegramID)
+	}
 
 	// Initialize handlers
 	api.InitUserHandlers(userStore)
@@ -50,12 +82,79 @@ func main() {
 	api.InitCardHandlers(cardStore)
 	api.InitWalletHandlers(walletStore)
 	api.InitAuditHandlers(auditStore)
+	api.InitIdempotency(idempotencyStore)
+	api.InitLedgerHandlers(ledgerStore)
+	api.InitTierHandlers(tierStore)
+	api.InitWithdrawalHandlers(withdrawalStore)
+	api.InitPromoHandlers(promoStore)
+	api.InitReferralHandlers(referralStore)
+	api.InitLimitHandlers(limitStore)
+	api.InitPatternHandlers(patternStore)
+	tokenSecret := os.Getenv("AUTH_TOKEN_SECRET")
+	if tokenSecret == "" {
+		log.Fatal("AUTH_TOKEN_SECRET environment variable not set")
+	}
+	api.InitAuth(os.Getenv("TELEGRAM_BOT_TOKEN"), tokenSecret)
+	api.InitServiceAuth(os.Getenv("SERVICE_SECRET"), serviceNonceStore)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "3000"
+	}
+	publicURL := os.Getenv("PUBLIC_API_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+	// The fake providers pay out on request and are for development only
+	allowFake := os.Getenv("ALLOW_FAKE_PAYMENTS") == "true"
+	paymentProvider, err := payment.NewProvider(os.Getenv("PAYMENT_PROVIDER"), os.Getenv("PAYMENT_WEBHOOK_SECRET"), publicURL, allowFake)
+	if err != nil {
+		log.Fatalf("Payment provider: %v", err)
+	}
+	api.InitPaymentHandlers(paymentProvider)
+	payoutProvider, err := payment.NewPayoutProvider(os.Getenv("PAYOUT_PROVIDER"), os.Getenv("PAYOUT_WEBHOOK_SECRET"), allowFake)
+	if err != nil {
+		log.Fatalf("Payout provider: %v", err)
+	}
+	api.InitPayoutHandlers(payoutProvider)
+	// Send approved withdrawals through the payout provider
+	payment.NewPayoutDispatcher(withdrawalStore, payoutProvider).Start()
+
+	// Draw the numbers of every active session on the server
+	scheduler.NewDrawScheduler(sessionStore).Start()
+
+	// Move rooms from countdown to game to completion and recycle them
+	lifecycle := scheduler.NewLifecycle(roomStore, sessionStore)
+	if v := os.Getenv("ROOM_RECYCLE_SECONDS"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			log.Fatalf("Invalid ROOM_RECYCLE_SECONDS %q", v)
+		}
+		lifecycle.RecycleAfter = time.Duration(seconds) * time.Second
+	}
+	api.InitLifecycle(lifecycle)
+	lifecycle.Start()
+
+	// Reconcile wallets and room escrows against the ledger, alerting admins
+	reconcileJob := reconcile.NewJob(ledgerStore)
+	if v := os.Getenv("RECONCILE_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			log.Fatalf("Invalid RECONCILE_INTERVAL_MINUTES %q", v)
+		}
+		reconcileJob.Interval = time.Duration(minutes) * time.Minute
+	}
+	reconcileJob.Freeze = os.Getenv("RECONCILE_FREEZE_WALLETS") == "true"
+	reconcileJob.Alert = telegrambot.NewAdminNotifier(os.Getenv("TELEGRAM_BOT_TOKEN"), adminTelegramIDs).Notify
+	api.InitReconciliation(reconcileJob)
+	reconcileJob.Start()
 
 	// Prepare config for bot
 	botConfig := &telegrambot.Config{
-		BotToken:   os.Getenv("TELEGRAM_BOT_TOKEN"),
-		APIBase:    os.Getenv("API_BASE"),
-		MiniAppURL: os.Getenv("MINIAPP_URL"),
+		BotToken:      os.Getenv("TELEGRAM_BOT_TOKEN"),
+		APIBase:       os.Getenv("API_BASE"),
+		MiniAppURL:    os.Getenv("MINIAPP_URL"),
+		ServiceSecret: os.Getenv("SERVICE_SECRET"),
 	}
 	go telegrambot.StartBot(botConfig)
 
@@ -68,17 +167,13 @@ func main() {
 	})
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*", // or "*" to allow all
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-User-ID",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, Idempotency-Key",
 		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
 	}))
 
 	// Register all API routes
 	api.RegisterRoutes(app)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
-	}
 	log.Printf("Server running on port %s", port)
 	log.Fatal(app.Listen(":" + port))
 }
@@ -1,20 +1,206 @@
 package api
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"net/url"
+	"rockbingo/internal/serviceauth"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func getUserID(c *fiber.Ctx) (int64, error) {
-	userIDStr := c.Get("X-User-ID")
-	if userIDStr == "" {
-		return 0, fiber.NewError(http.StatusUnauthorized, "User ID not provided")
+// fiber.Ctx locals keys holding the authenticated internal user ID and role.
+const (
+	userIDKey   = "userID"
+	userRoleKey = "userRole"
+)
+
+// initDataMaxAge limits how long a signed Mini App initData payload is accepted.
+const initDataMaxAge = 24 * time.Hour
+
+var botToken string
+
+// InitAuth sets the Telegram bot token used to verify Mini App initData and
+// the secret used to sign API session tokens.
+func InitAuth(token, secret string) {
+	botToken = token
+	tokenSecret = []byte(secret)
+}
+
+// telegramUser is the "user" field of a Telegram WebApp initData payload.
+type telegramUser struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// validateInitData checks the initData HMAC against the bot token as described in
+// https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+// and returns the Telegram user it was issued for.
+func validateInitData(initData string) (*telegramUser, error) {
+	if botToken == "" {
+		return nil, errors.New("telegram auth is not configured")
 	}
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	values, err := url.ParseQuery(initData)
 	if err != nil {
-		return 0, fiber.NewError(http.StatusBadRequest, "Invalid User ID")
+		return nil, errors.New("malformed init data")
+	}
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, errors.New("init data is not signed")
+	}
+	values.Del("hash")
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	got, err := hex.DecodeString(hash)
+	if err != nil || !hmac.Equal(mac.Sum(nil), got) {
+		return nil, errors.New("invalid init data signature")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid auth_date")
+	}
+	if time.Since(time.Unix(authDate, 0)) > initDataMaxAge {
+		return nil, errors.New("init data expired")
+	}
+
+	var user telegramUser
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, errors.New("init data has no user")
+	}
+	return &user, nil
+}
+
+// RequireAuth authenticates the request from the "Authorization: Bearer <token>"
+// access token issued by /auth/telegram, or from a bot request signed with the
+// service secret, and stores the internal user ID for getUserID. Tokens whose
+// version no longer matches the user are rejected.
+func RequireAuth(c *fiber.Ctx) error {
+	if c.Get(serviceauth.HeaderSignature) != "" {
+		return authenticateService(c)
+	}
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || token == "" {
+		return fiber.NewError(http.StatusUnauthorized, "Access token not provided")
+	}
+	claims, err := parseToken(token, tokenTypeAccess)
+	if err != nil {
+		return fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	user, err := userStore.GetByID(context.Background(), claims.UserID)
+	if err != nil || user.TokenVersion != claims.Version {
+		return fiber.NewError(http.StatusUnauthorized, "Token revoked")
+	}
+	c.Locals(userIDKey, user.ID)
+	c.Locals(userRoleKey, user.Role)
+	return c.Next()
+}
+
+// RequireRole only lets through requests whose authenticated user has one of
+// the given roles. It must run after RequireAuth.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals(userRoleKey).(string)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+		return fiber.NewError(http.StatusForbidden, "Insufficient permissions")
+	}
+}
+
+func getUserID(c *fiber.Ctx) (int64, error) {
+	userID, ok := c.Locals(userIDKey).(int64)
+	if !ok || userID == 0 {
+		return 0, fiber.NewError(http.StatusUnauthorized, "User not authenticated")
 	}
 	return userID, nil
 }
+
+func TelegramAuthHandler(c *fiber.Ctx) error {
+	type req struct {
+		InitData string `json:"init_data"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		log.Printf("[TelegramAuthHandler] BodyParser error: %v", err)
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	tgUser, err := validateInitData(body.InitData)
+	if err != nil {
+		log.Printf("[TelegramAuthHandler] init data rejected: %v", err)
+		return fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	user, err := userStore.FindOrCreateByTelegram(context.Background(), tgUser.ID, tgUser.Username, tgUser.FirstName, tgUser.LastName)
+	if err != nil {
+		log.Printf("[TelegramAuthHandler] FindOrCreateByTelegram error: %v", err)
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	log.Printf("[TelegramAuthHandler] Auth success: user %d (telegram %d)", user.ID, user.TelegramID)
+	return c.JSON(fiber.Map{
+		"user":   user,
+		"tokens": issueTokens(user.ID, user.TokenVersion),
+	})
+}
+
+func RefreshTokenHandler(c *fiber.Ctx) error {
+	type req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	claims, err := parseToken(body.RefreshToken, tokenTypeRefresh)
+	if err != nil {
+		return fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	user, err := userStore.GetByID(context.Background(), claims.UserID)
+	if err != nil || user.TokenVersion != claims.Version {
+		return fiber.NewError(http.StatusUnauthorized, "Token revoked")
+	}
+	return c.JSON(issueTokens(user.ID, user.TokenVersion))
+}
+
+// LogoutHandler revokes every token issued to the calling user.
+func LogoutHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	if err := userStore.RevokeTokens(context.Background(), userID); err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func RegisterAuthRoutes(router fiber.Router) {
+	router.Post("/auth/telegram", TelegramAuthHandler)
+	router.Post("/auth/refresh", RefreshTokenHandler)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"rockbingo/internal/db"
@@ -16,25 +17,6 @@ func InitCardHandlers(store *db.CardStore) {
 	cardStore = store
 }
 
-func CreateCardHandler(c *fiber.Ctx) error {
-	userID, err := getUserID(c)
-	if err != nil {
-		return err
-	}
-	type req struct {
-		RoomID int64 `json:"room_id"`
-	}
-	var body req
-	if err := c.BodyParser(&body); err != nil {
-		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
-	}
-	card, err := cardStore.CreateCard(context.Background(), userID, body.RoomID)
-	if err != nil {
-		return fiber.NewError(http.StatusInternalServerError, err.Error())
-	}
-	return c.JSON(card)
-}
-
 func GetCardHandler(c *fiber.Ctx) error {
 	cardID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
@@ -79,6 +61,18 @@ func SelectCardHandler(c *fiber.Ctx) error {
 	}
 
 	err = cardStore.SelectCard(context.Background(), roomID, body.CardNumber, userID)
+	if errors.Is(err, db.ErrInsufficientBalance) {
+		return fiber.NewError(http.StatusBadRequest, "Insufficient balance")
+	}
+	if errors.Is(err, db.ErrWalletFrozen) {
+		return fiber.NewError(http.StatusForbidden, "Wallet is frozen")
+	}
+	if isLimitError(err) {
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	}
+	if errors.Is(err, db.ErrRoomNotWaiting) || errors.Is(err, db.ErrCardAlreadySelected) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
@@ -110,9 +104,8 @@ func GetUserSelectedCardHandler(c *fiber.Ctx) error {
 }
 
 func RegisterCardRoutes(router fiber.Router) {
-	router.Post("/cards", CreateCardHandler)
 	router.Get("/cards/:id", GetCardHandler)
 	router.Get("/rooms/:roomId/available-cards", GetAvailableCardsHandler)
-	router.Post("/rooms/:roomId/select-card", SelectCardHandler)
+	router.Post("/rooms/:roomId/select-card", Idempotent, SelectCardHandler)
 	router.Get("/rooms/:roomId/my-card", GetUserSelectedCardHandler)
 }
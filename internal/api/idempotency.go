@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"rockbingo/internal/db"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const headerIdempotencyKey = "Idempotency-Key"
+
+var idempotencyStore *db.IdempotencyStore
+
+func InitIdempotency(store *db.IdempotencyStore) {
+	idempotencyStore = store
+}
+
+// Idempotent makes a POST route safe to retry. When the request carries an
+// Idempotency-Key header, the first successful response for that key is stored
+// and replayed for every later request with the same key instead of running
+// the handler again. Failed requests release the key so they can be retried.
+func Idempotent(c *fiber.Ctx) error {
+	key := c.Get(headerIdempotencyKey)
+	if key == "" {
+		return c.Next()
+	}
+	if len(key) > 255 {
+		return fiber.NewError(http.StatusBadRequest, "Idempotency-Key is too long")
+	}
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(append([]byte(c.Method()+" "+c.Path()+"\n"), c.Body()...))
+	requestHash := hex.EncodeToString(sum[:])
+
+	rec, created, err := idempotencyStore.Begin(context.Background(), userID, key, c.Method(), c.Path(), requestHash)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if !created {
+		if rec.RequestHash != requestHash {
+			return fiber.NewError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		}
+		if rec.CompletedAt == nil || rec.StatusCode == nil {
+			return fiber.NewError(http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		}
+		c.Set("Idempotent-Replayed", "true")
+		if rec.ContentType != nil && *rec.ContentType != "" {
+			c.Set(fiber.HeaderContentType, *rec.ContentType)
+		}
+		return c.Status(*rec.StatusCode).Send(rec.ResponseBody)
+	}
+
+	err = c.Next()
+	status := c.Response().StatusCode()
+	if err != nil || status >= http.StatusInternalServerError {
+		if rerr := idempotencyStore.Release(context.Background(), rec.ID); rerr != nil {
+			log.Printf("[Idempotent] Failed to release key %d: %v", rec.ID, rerr)
+		}
+		return err
+	}
+	body := append([]byte(nil), c.Response().Body()...)
+	contentType := string(c.Response().Header.ContentType())
+	if cerr := idempotencyStore.Complete(context.Background(), rec.ID, status, contentType, body); cerr != nil {
+		log.Printf("[Idempotent] Failed to store response for key %d: %v", rec.ID, cerr)
+	}
+	return nil
+}
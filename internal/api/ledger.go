@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"rockbingo/internal/reconcile"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ledgerStore  *db.LedgerStore
+	reconcileJob *reconcile.Job
+)
+
+func InitLedgerHandlers(store *db.LedgerStore) {
+	ledgerStore = store
+}
+
+// InitReconciliation sets the job run on demand by the admin routes
+func InitReconciliation(job *reconcile.Job) {
+	reconcileJob = job
+}
+
+// ReconcileWalletHandler compares a user's cached wallet balance with the
+// balance derived from the ledger.
+func ReconcileWalletHandler(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid user ID")
+	}
+	cached, ledger, err := ledgerStore.ReconcileWallet(context.Background(), userID)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Wallet not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"user_id":        userID,
+		"cached_balance": cached,
+		"ledger_balance": ledger,
+		"difference":     cached.Sub(ledger),
+		"consistent":     cached.Cmp(ledger) == 0,
+	})
+}
+
+// ListDiscrepanciesHandler lists reconciliation discrepancies:
+// ?status=open (default), resolved or all.
+func ListDiscrepanciesHandler(c *fiber.Ctx) error {
+	status := c.Query("status", "open")
+	if status != "open" && status != "resolved" && status != "all" {
+		return fiber.NewError(http.StatusBadRequest, "Invalid status")
+	}
+	list, err := ledgerStore.ListDiscrepancies(context.Background(), status)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(list)
+}
+
+// RunReconciliationHandler reconciles now instead of waiting for the next run.
+func RunReconciliationHandler(c *fiber.Ctx) error {
+	opened, found, err := reconcileJob.RunOnce(context.Background())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"opened": opened,
+		"found":  found,
+	})
+}
+
+// ResolveDiscrepancyHandler closes an open discrepancy with an optional
+// {"note": "..."} from the operator.
+func ResolveDiscrepancyHandler(c *fiber.Ctx) error {
+	operatorID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid discrepancy ID")
+	}
+	type req struct {
+		Note string `json:"note"`
+	}
+	var body req
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+		}
+	}
+	d, err := ledgerStore.ResolveDiscrepancy(context.Background(), id, operatorID, body.Note)
+	if errors.Is(err, db.ErrDiscrepancyNotFound) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(d)
+}
+
+// FreezeWalletHandler stops a user from spending or withdrawing, with a
+// {"reason": "..."} from the operator.
+func FreezeWalletHandler(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid user ID")
+	}
+	type req struct {
+		Reason string `json:"reason"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil || body.Reason == "" {
+		return fiber.NewError(http.StatusBadRequest, "A reason is required")
+	}
+	err = ledgerStore.FreezeWallet(context.Background(), userID, body.Reason)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Wallet not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func UnfreezeWalletHandler(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("userId"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid user ID")
+	}
+	err = ledgerStore.UnfreezeWallet(context.Background(), userID)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Wallet not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// RegisterLedgerAdminRoutes registers ledger routes on the /admin group.
+func RegisterLedgerAdminRoutes(router fiber.Router) {
+	router.Get("/ledger/wallets/:userId", ReconcileWalletHandler)
+	router.Get("/reconciliation/discrepancies", ListDiscrepanciesHandler)
+	router.Post("/reconciliation/run", RunReconciliationHandler)
+	router.Post("/reconciliation/discrepancies/:id/resolve", ResolveDiscrepancyHandler)
+	router.Post("/wallets/:userId/freeze", FreezeWalletHandler)
+	router.Post("/wallets/:userId/unfreeze", UnfreezeWalletHandler)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"rockbingo/internal/db"
+	"rockbingo/internal/scheduler"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var lifecycle *scheduler.Lifecycle
+
+// InitLifecycle sets the room lifecycle worker reported on and run by the admin routes
+func InitLifecycle(worker *scheduler.Lifecycle) {
+	lifecycle = worker
+}
+
+// GetLifecycleHandler returns the lifecycle worker's metrics, how many rooms
+// are in each stage and the rooms whose countdown ended without a session.
+func GetLifecycleHandler(c *fiber.Ctx) error {
+	ctx := context.Background()
+	counts, err := lifecycle.Rooms.CountRoomStates(ctx)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	stuck, err := lifecycle.Rooms.DueRooms(ctx)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if stuck == nil {
+		stuck = []db.BingoRoom{}
+	}
+	return c.JSON(fiber.Map{
+		"metrics":               lifecycle.Metrics(),
+		"rooms":                 counts,
+		"stuck_rooms":           stuck,
+		"tick_seconds":          int(lifecycle.Tick.Seconds()),
+		"recycle_after_seconds": int(lifecycle.RecycleAfter.Seconds()),
+	})
+}
+
+// GetStuckRoomsHandler lists the rooms whose countdown ended without a session.
+func GetStuckRoomsHandler(c *fiber.Ctx) error {
+	rooms, err := lifecycle.Rooms.DueRooms(context.Background())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if rooms == nil {
+		rooms = []db.BingoRoom{}
+	}
+	return c.JSON(rooms)
+}
+
+// RunLifecycleHandler runs the lifecycle worker now and returns what it did.
+func RunLifecycleHandler(c *fiber.Ctx) error {
+	return c.JSON(lifecycle.RunOnce(context.Background()))
+}
+
+// RegisterLifecycleAdminRoutes registers the room lifecycle routes on the /admin group.
+func RegisterLifecycleAdminRoutes(router fiber.Router) {
+	router.Get("/lifecycle", GetLifecycleHandler)
+	router.Post("/lifecycle/run", RunLifecycleHandler)
+	router.Get("/stuck-rooms", GetStuckRoomsHandler)
+	router.Post("/recover-stuck-rooms", RunLifecycleHandler)
+}
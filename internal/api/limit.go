@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var limitStore *db.LimitStore
+
+func InitLimitHandlers(store *db.LimitStore) {
+	limitStore = store
+}
+
+// isLimitError reports whether err is a refusal by the user's responsible
+// gaming limits or self-exclusion
+func isLimitError(err error) bool {
+	return errors.Is(err, db.ErrSelfExcluded) || errors.Is(err, db.ErrDepositLimit) ||
+		errors.Is(err, db.ErrLossLimit) || errors.Is(err, db.ErrPlayTimeLimit)
+}
+
+// GetLimitsHandler returns the caller's responsible gaming limits with what
+// each has used, and their self-exclusion if one is in effect.
+func GetLimitsHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	limits, err := limitStore.ListLimits(ctx, userID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	exclusion, err := limitStore.GetSelfExclusion(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"limits":            limits,
+		"self_exclusion":    exclusion,
+		"cooling_off_hours": int(db.GetLimitConfig().CoolingOff / time.Hour),
+	})
+}
+
+// SetLimitHandler sets a deposit, loss or play time limit for a period.
+// Lowering a limit applies at once, raising it after the cooling-off period.
+func SetLimitHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		Kind    string    `json:"kind"`
+		Period  string    `json:"period"`
+		Amount  *db.Money `json:"amount"`
+		Minutes *int      `json:"minutes"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	limit := db.GamingLimit{
+		UserID:  userID,
+		Kind:    body.Kind,
+		Period:  body.Period,
+		Amount:  body.Amount,
+		Minutes: body.Minutes,
+	}
+	if err := limit.Validate(); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	saved, err := limitStore.SetLimit(context.Background(), limit)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(saved)
+}
+
+// RemoveLimitHandler removes one of the caller's limits after the cooling-off period.
+func RemoveLimitHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	limit, err := limitStore.RemoveLimit(context.Background(), userID, c.Params("kind"), c.Params("period"))
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Limit not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(limit)
+}
+
+// SelfExcludeHandler excludes the caller from depositing and playing for a
+// number of days. Withdrawals stay open.
+func SelfExcludeHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		Days int `json:"days"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	if body.Days < 1 || body.Days > 3650 {
+		return fiber.NewError(http.StatusBadRequest, "days must be between 1 and 3650")
+	}
+	ex, err := limitStore.SelfExclude(context.Background(), userID, time.Duration(body.Days)*24*time.Hour)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(ex)
+}
+
+// EndSelfExclusionHandler ends the caller's self-exclusion after the cooling-off period.
+func EndSelfExclusionHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	ex, err := limitStore.EndSelfExclusion(context.Background(), userID)
+	if errors.Is(err, db.ErrNotSelfExcluded) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(ex)
+}
+
+func RegisterLimitRoutes(router fiber.Router) {
+	router.Get("/limits", GetLimitsHandler)
+	router.Put("/limits", SetLimitHandler)
+	router.Delete("/limits/:kind/:period", RemoveLimitHandler)
+	router.Post("/self-exclusion", SelfExcludeHandler)
+	router.Delete("/self-exclusion", EndSelfExclusionHandler)
+}
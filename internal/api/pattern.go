@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"rockbingo/internal/game"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var patternStore *db.PatternStore
+
+func InitPatternHandlers(store *db.PatternStore) {
+	patternStore = store
+}
+
+// ListPatternsHandler lists the built-in and custom win patterns of every variant.
+func ListPatternsHandler(c *fiber.Ctx) error {
+	patterns, err := patternStore.ListPatterns(context.Background())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(patterns)
+}
+
+// CreatePatternHandler uploads a custom win pattern: a variant, a name and a
+// list of masks the size of the variant's cards, true for each cell to
+// cover. A card wins with any one of them.
+func CreatePatternHandler(c *fiber.Ctx) error {
+	operatorID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		Variant string      `json:"variant"`
+		Name    string      `json:"name"`
+		Masks   []game.Mask `json:"masks"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	variant, err := parseVariant(body.Variant)
+	if err != nil {
+		return err
+	}
+	pattern, err := game.NewPattern(variant.Name(), body.Name, body.Masks)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	created, err := patternStore.CreatePattern(context.Background(), pattern, operatorID)
+	if errors.Is(err, db.ErrDuplicatePattern) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(created)
+}
+
+// DeletePatternHandler deletes a custom win pattern no waiting room is set
+// to. The variant query parameter defaults to 75-ball.
+func DeletePatternHandler(c *fiber.Ctx) error {
+	variant, err := parseVariant(c.Query("variant"))
+	if err != nil {
+		return err
+	}
+	err = patternStore.DeletePattern(context.Background(), variant.Name(), c.Params("name"))
+	if errors.Is(err, db.ErrPatternNotFound) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, db.ErrPatternInUse) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// parseVariant looks up the card variant a request names; none means 75-ball.
+func parseVariant(name string) (game.Variant, error) {
+	v, ok := game.LookupVariant(name)
+	if !ok {
+		return nil, fiber.NewError(http.StatusBadRequest, "Unknown variant")
+	}
+	return v, nil
+}
+
+func RegisterPatternRoutes(router fiber.Router) {
+	router.Get("/patterns", ListPatternsHandler)
+}
+
+// RegisterPatternAdminRoutes registers the custom pattern routes on the /admin group.
+func RegisterPatternAdminRoutes(router fiber.Router) {
+	router.Post("/patterns", CreatePatternHandler)
+	router.Delete("/patterns/:name", DeletePatternHandler)
+}
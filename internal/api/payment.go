@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"rockbingo/internal/db"
+	"rockbingo/internal/payment"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	paymentProvider payment.PaymentProvider
+	payoutProvider  payment.PayoutProvider
+)
+
+// InitPaymentHandlers sets the provider deposits are collected through.
+func InitPaymentHandlers(provider payment.PaymentProvider) {
+	paymentProvider = provider
+}
+
+// InitPayoutHandlers sets the provider withdrawals are paid out through.
+func InitPayoutHandlers(provider payment.PayoutProvider) {
+	payoutProvider = provider
+}
+
+// PaymentWebhookHandler receives the provider's signed callbacks about
+// deposit payments. It is the only path that credits a deposit.
+func PaymentWebhookHandler(c *fiber.Ctx) error {
+	if c.Params("provider") != paymentProvider.Name() {
+		return fiber.NewError(http.StatusNotFound, "Unknown payment provider")
+	}
+	dep, err := processPaymentWebhook(http.Header(c.GetReqHeaders()), c.Body())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"received": true, "status": dep.Status})
+}
+
+// processPaymentWebhook verifies a webhook and settles the deposit it is about
+func processPaymentWebhook(header http.Header, body []byte) (*db.PaymentDeposit, error) {
+	event, err := paymentProvider.VerifyWebhook(header, body)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		return nil, fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	if err != nil {
+		return nil, fiber.NewError(http.StatusBadRequest, "Invalid webhook payload")
+	}
+	dep, err := walletStore.SettleDeposit(context.Background(), paymentProvider.Name(),
+		event.Reference, string(event.Status), event.Amount)
+	if err == sql.ErrNoRows {
+		return nil, fiber.NewError(http.StatusNotFound, "Deposit not found")
+	}
+	if errors.Is(err, db.ErrDepositAmountMismatch) {
+		log.Printf("[processPaymentWebhook] Deposit %s confirmed for %s: %v", event.Reference, event.Amount, err)
+		return nil, fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return nil, fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	log.Printf("[processPaymentWebhook] Deposit %s is %s", dep.TransactionRef, dep.Status)
+	return dep, nil
+}
+
+// FakeCheckoutHandler is the checkout page of the fake provider. Opening it
+// pays the deposit (or fails it with ?result=failed) and delivers the
+// provider's signed webhook, exactly as the webhook route would receive it.
+func FakeCheckoutHandler(c *fiber.Ctx) error {
+	fake, ok := paymentProvider.(*payment.FakeProvider)
+	if !ok {
+		return fiber.NewError(http.StatusNotFound, "Not found")
+	}
+	status := payment.StatusCompleted
+	if c.Query("result") == string(payment.StatusFailed) {
+		status = payment.StatusFailed
+	}
+	header, body, err := fake.Pay(c.Params("ref"), status)
+	if errors.Is(err, payment.ErrUnknownCheckout) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	dep, err := processPaymentWebhook(header, body)
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"transaction_ref": dep.TransactionRef, "status": dep.Status, "amount": dep.Amount})
+}
+
+// PayoutCallbackHandler receives the payout provider's signed reports on
+// withdrawals it was sent.
+func PayoutCallbackHandler(c *fiber.Ctx) error {
+	if c.Params("provider") != payoutProvider.Name() {
+		return fiber.NewError(http.StatusNotFound, "Unknown payout provider")
+	}
+	wr, err := processPayoutCallback(http.Header(c.GetReqHeaders()), c.Body())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"received": true, "status": wr.Status})
+}
+
+// processPayoutCallback verifies a payout callback and settles the withdrawal it is about
+func processPayoutCallback(header http.Header, body []byte) (*db.WithdrawalRequest, error) {
+	event, err := payoutProvider.VerifyCallback(header, body)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		return nil, fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	if err != nil {
+		return nil, fiber.NewError(http.StatusBadRequest, "Invalid callback payload")
+	}
+	if event.Status != payment.StatusCompleted && event.Status != payment.StatusFailed {
+		return withdrawalStore.GetWithdrawalByReference(context.Background(), event.Reference)
+	}
+	wr, err := withdrawalStore.CompletePayout(context.Background(), event.Reference, event.ProviderRef,
+		event.Status == payment.StatusCompleted, &event.Amount)
+	if err == sql.ErrNoRows {
+		return nil, fiber.NewError(http.StatusNotFound, "Withdrawal not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	log.Printf("[processPayoutCallback] Withdrawal %d is %s", wr.ID, wr.Status)
+	return wr, nil
+}
+
+// FakePayoutSettleHandler completes a payout sent to the fake payout
+// provider (or fails it with ?result=failed) and delivers its callback.
+func FakePayoutSettleHandler(c *fiber.Ctx) error {
+	fake, ok := payoutProvider.(*payment.FakePayoutProvider)
+	if !ok {
+		return fiber.NewError(http.StatusNotFound, "Not found")
+	}
+	status := payment.StatusCompleted
+	if c.Query("result") == string(payment.StatusFailed) {
+		status = payment.StatusFailed
+	}
+	header, body, err := fake.Settle(c.Params("reference"), status)
+	if errors.Is(err, payment.ErrUnknownPayout) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	wr, err := processPayoutCallback(header, body)
+	if err != nil {
+		return err
+	}
+	return c.JSON(wr)
+}
+
+// RegisterPaymentRoutes registers the public payment provider routes. They
+// are authenticated by the provider's signature, not a user token. The fake
+// checkout page is only there when the fake provider was opted in to.
+func RegisterPaymentRoutes(router fiber.Router) {
+	router.Post("/payments/:provider/webhook", PaymentWebhookHandler)
+	if _, ok := paymentProvider.(*payment.FakeProvider); ok {
+		router.Get("/payments/fake/checkout/:ref", FakeCheckoutHandler)
+	}
+	router.Post("/payouts/:provider/callback", PayoutCallbackHandler)
+}
+
+// RegisterPaymentAdminRoutes registers payment routes on the /admin group.
+func RegisterPaymentAdminRoutes(router fiber.Router) {
+	if _, ok := payoutProvider.(*payment.FakePayoutProvider); ok {
+		router.Post("/payouts/fake/:reference/settle", FakePayoutSettleHandler)
+	}
+}
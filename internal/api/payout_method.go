@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListPayoutMethodsHandler lists the caller's saved payout methods.
+func ListPayoutMethodsHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	methods, err := withdrawalStore.ListPayoutMethods(context.Background(), userID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(methods)
+}
+
+// AddPayoutMethodHandler saves a payout method for the caller. It can be
+// withdrawn to once an operator has verified it.
+func AddPayoutMethodHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		db.PayoutDestination
+		Label string `json:"label"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	destination, err := body.PayoutDestination.Normalize()
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if len(body.Label) > 64 {
+		return fiber.NewError(http.StatusBadRequest, "Label must be at most 64 characters")
+	}
+	method, err := withdrawalStore.AddPayoutMethod(context.Background(), userID, destination, body.Label)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(method)
+}
+
+func RemovePayoutMethodHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid payout method ID")
+	}
+	err = withdrawalStore.RemovePayoutMethod(context.Background(), userID, id)
+	if errors.Is(err, db.ErrPayoutMethodNotFound) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"message": "Payout method removed"})
+}
+
+// ListUnverifiedPayoutMethodsHandler lists payout methods waiting for verification.
+func ListUnverifiedPayoutMethodsHandler(c *fiber.Ctx) error {
+	methods, err := withdrawalStore.ListUnverifiedPayoutMethods(context.Background())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(methods)
+}
+
+// VerifyPayoutMethodHandler marks a payout method as checked to belong to its user.
+func VerifyPayoutMethodHandler(c *fiber.Ctx) error {
+	operatorID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid payout method ID")
+	}
+	method, err := withdrawalStore.VerifyPayoutMethod(context.Background(), id, operatorID)
+	if errors.Is(err, db.ErrPayoutMethodNotFound) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(method)
+}
+
+func RegisterPayoutMethodRoutes(router fiber.Router) {
+	router.Get("/payout-methods", ListPayoutMethodsHandler)
+	router.Post("/payout-methods", AddPayoutMethodHandler)
+	router.Delete("/payout-methods/:id", RemovePayoutMethodHandler)
+}
+
+// RegisterPayoutMethodAdminRoutes registers payout method review routes on the /admin group.
+func RegisterPayoutMethodAdminRoutes(router fiber.Router) {
+	router.Get("/payout-methods/unverified", ListUnverifiedPayoutMethodsHandler)
+	router.Post("/payout-methods/:id/verify", VerifyPayoutMethodHandler)
+}
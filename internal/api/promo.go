@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var promoStore *db.PromoStore
+
+func InitPromoHandlers(store *db.PromoStore) {
+	promoStore = store
+}
+
+// RedeemPromoHandler applies a promo code for the caller.
+func RedeemPromoHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		Code string `json:"code"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil || body.Code == "" {
+		return fiber.NewError(http.StatusBadRequest, "A promo code is required")
+	}
+	red, err := promoStore.Redeem(context.Background(), userID, body.Code)
+	if errors.Is(err, db.ErrPromoNotFound) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, db.ErrPromoExpired) || errors.Is(err, db.ErrPromoExhausted) {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, db.ErrPromoAlreadyRedeemed) || errors.Is(err, db.ErrBonusActive) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(red)
+}
+
+// GetBonusHandler returns the caller's bonus balance and promo redemptions,
+// with the wagering progress of each.
+func GetBonusHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	wallet, err := walletStore.GetWallet(context.Background(), userID)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Wallet not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	reds, err := promoStore.ListUserRedemptions(context.Background(), userID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if reds == nil {
+		reds = []db.UserRedemption{}
+	}
+	return c.JSON(fiber.Map{
+		"bonus_balance": wallet.BonusBalance,
+		"redemptions":   reds,
+	})
+}
+
+func ListPromoCodesHandler(c *fiber.Ctx) error {
+	promos, err := promoStore.ListPromoCodes(context.Background())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(promos)
+}
+
+// CreatePromoCodeHandler creates a promo code: a fixed bonus of amount, or a
+// deposit match of match_bps basis points of the next deposit up to
+// max_bonus, to be wagered wagering_multiplier times.
+func CreatePromoCodeHandler(c *fiber.Ctx) error {
+	operatorID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		Code               string     `json:"code"`
+		Kind               string     `json:"kind"`
+		Amount             db.Money   `json:"amount"`
+		MatchBps           int        `json:"match_bps"`
+		MaxBonus           *db.Money  `json:"max_bonus"`
+		WageringMultiplier int        `json:"wagering_multiplier"`
+		ExpiresAt          *time.Time `json:"expires_at"`
+		MaxRedemptions     *int       `json:"max_redemptions"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	promo := db.PromoCode{
+		Code:               db.NormalizePromoCode(body.Code),
+		Kind:               body.Kind,
+		Amount:             body.Amount,
+		MatchBps:           body.MatchBps,
+		MaxBonus:           body.MaxBonus,
+		WageringMultiplier: body.WageringMultiplier,
+		ExpiresAt:          body.ExpiresAt,
+		MaxRedemptions:     body.MaxRedemptions,
+	}
+	if err := promo.Validate(); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if promo.ExpiresAt != nil && !promo.ExpiresAt.After(time.Now()) {
+		return fiber.NewError(http.StatusBadRequest, "expires_at must be in the future")
+	}
+	created, err := promoStore.CreatePromoCode(context.Background(), promo, operatorID)
+	if errors.Is(err, db.ErrDuplicatePromoCode) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(created)
+}
+
+func DeactivatePromoCodeHandler(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid promo code ID")
+	}
+	promo, err := promoStore.DeactivatePromoCode(context.Background(), id)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Promo code not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(promo)
+}
+
+func RegisterPromoRoutes(router fiber.Router) {
+	router.Post("/promo/redeem", RedeemPromoHandler)
+	router.Get("/bonus", GetBonusHandler)
+}
+
+// RegisterPromoAdminRoutes registers promo code routes on the /admin group.
+func RegisterPromoAdminRoutes(router fiber.Router) {
+	router.Get("/promo-codes", ListPromoCodesHandler)
+	router.Post("/promo-codes", CreatePromoCodeHandler)
+	router.Post("/promo-codes/:id/deactivate", DeactivatePromoCodeHandler)
+}
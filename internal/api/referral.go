@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var referralStore *db.ReferralStore
+
+func InitReferralHandlers(store *db.ReferralStore) {
+	referralStore = store
+}
+
+// RecordReferralHandler records who invited the caller, from the payload of
+// the referral link they opened the bot with.
+func RecordReferralHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	type req struct {
+		ReferrerTelegramID int64 `json:"referrer_telegram_id"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil || body.ReferrerTelegramID == 0 {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	ref, err := referralStore.RecordReferral(context.Background(), userID, body.ReferrerTelegramID)
+	if errors.Is(err, db.ErrReferrerNotFound) {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if errors.Is(err, db.ErrSelfReferral) {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, db.ErrReferralNotEligible) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(ref)
+}
+
+// GetReferralStatsHandler returns the users the caller invited, how many of
+// them qualified and the rewards earned, with the current reward terms.
+// Referees' own deposits and stakes are not disclosed.
+func GetReferralStatsHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	refs, err := referralStore.ListReferrals(context.Background(), userID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	type referee struct {
+		Username    string     `json:"username"`
+		FirstName   string     `json:"first_name"`
+		JoinedAt    time.Time  `json:"joined_at"`
+		QualifiedAt *time.Time `json:"qualified_at"`
+		Reward      db.Money   `json:"reward"`
+	}
+	cfg := db.GetReferralConfig()
+	referees := []referee{}
+	qualified := 0
+	earned := db.NewMoney(0, db.DefaultCurrency)
+	for _, r := range refs {
+		referees = append(referees, referee{
+			Username:    r.Username,
+			FirstName:   r.FirstName,
+			JoinedAt:    r.CreatedAt,
+			QualifiedAt: r.QualifiedAt,
+			Reward:      r.ReferrerReward,
+		})
+		if r.QualifiedAt != nil {
+			qualified++
+			earned = earned.Add(r.ReferrerReward)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"referrals":       len(refs),
+		"qualified":       qualified,
+		"earned":          earned,
+		"referees":        referees,
+		"referrer_reward": cfg.ReferrerReward,
+		"referee_reward":  cfg.RefereeReward,
+		"min_deposit":     cfg.MinDeposit,
+		"min_wagered":     cfg.MinWagered,
+	})
+}
+
+func RegisterReferralRoutes(router fiber.Router) {
+	router.Post("/referrals", RecordReferralHandler)
+	router.Get("/referrals", GetReferralStatsHandler)
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"rockbingo/internal/db"
@@ -18,20 +20,43 @@ func InitRoomHandlers(store *db.RoomStore) {
 
 func CreateRoomHandler(c *fiber.Ctx) error {
 	type req struct {
-		BetAmount  float64 `json:"bet_amount"`
-		MaxPlayers int     `json:"max_players"`
+		BetAmount  db.Money `json:"bet_amount"`
+		MaxPlayers int      `json:"max_players"`
+		WinPattern string   `json:"win_pattern"`
+		Variant    string   `json:"variant"`
 	}
 	var body req
 	if err := c.BodyParser(&body); err != nil {
 		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
 	}
+	if !body.BetAmount.IsPositive() {
+		return fiber.NewError(http.StatusBadRequest, "Bet amount must be positive")
+	}
 	if body.MaxPlayers <= 0 {
 		body.MaxPlayers = 100
 	}
-	room, err := roomStore.CreateRoom(context.Background(), body.BetAmount, body.MaxPlayers)
+	variant, err := parseVariant(body.Variant)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if body.WinPattern != "" {
+		if _, err := patternStore.GetPattern(ctx, variant.Name(), body.WinPattern); errors.Is(err, db.ErrPatternNotFound) {
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		} else if err != nil {
+			return fiber.NewError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	room, err := roomStore.CreateRoom(ctx, body.BetAmount, body.MaxPlayers, variant)
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
+	if body.WinPattern != "" {
+		room, err = roomStore.SetRoomPattern(ctx, room.ID, body.WinPattern)
+		if err != nil {
+			return fiber.NewError(http.StatusInternalServerError, err.Error())
+		}
+	}
 	return c.JSON(room)
 }
 
@@ -55,6 +80,23 @@ func GetRoomHandler(c *fiber.Ctx) error {
 	return c.JSON(room)
 }
 
+// GetRoomRulesHandler returns how a room's game is played: its win pattern,
+// draw interval, claim window and house commission.
+func GetRoomRulesHandler(c *fiber.Ctx) error {
+	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid room ID")
+	}
+	rules, err := roomStore.GetRoomRules(context.Background(), roomID)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Room not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(rules)
+}
+
 func JoinRoomHandler(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
@@ -75,6 +117,18 @@ func JoinRoomHandler(c *fiber.Ctx) error {
 		if err.Error() == "user already in room" {
 			return fiber.NewError(http.StatusConflict, "User already joined this room")
 		}
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			return fiber.NewError(http.StatusBadRequest, "Insufficient balance")
+		}
+		if errors.Is(err, db.ErrWalletFrozen) {
+			return fiber.NewError(http.StatusForbidden, "Wallet is frozen")
+		}
+		if isLimitError(err) {
+			return fiber.NewError(http.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, db.ErrRoomNotWaiting) {
+			return fiber.NewError(http.StatusConflict, err.Error())
+		}
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -143,44 +197,37 @@ func GetRoomCardsHandler(c *fiber.Ctx) error {
 	return c.JSON(cards)
 }
 
-func PlaceBetHandler(c *fiber.Ctx) error {
+func FindOrCreateRoomHandler(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
-	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
-	if err != nil {
-		return fiber.NewError(http.StatusBadRequest, "Invalid room ID")
-	}
+
 	type req struct {
-		BingoCardID int64   `json:"bingo_card_id"`
-		BetAmount   float64 `json:"bet_amount"`
+		BetAmount db.Money `json:"bet_amount"`
+		Variant   string   `json:"variant"`
 	}
 	var body req
 	if err := c.BodyParser(&body); err != nil {
 		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
 	}
-	if err := roomStore.PlaceBet(context.Background(), userID, roomID, body.BingoCardID, body.BetAmount); err != nil {
-		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	if !body.BetAmount.IsPositive() {
+		return fiber.NewError(http.StatusBadRequest, "Bet amount must be positive")
 	}
-	return c.SendStatus(http.StatusNoContent)
-}
-
-func FindOrCreateRoomHandler(c *fiber.Ctx) error {
-	userID, err := getUserID(c)
+	variant, err := parseVariant(body.Variant)
 	if err != nil {
 		return err
 	}
 
-	type req struct {
-		BetAmount float64 `json:"bet_amount"`
-	}
-	var body req
-	if err := c.BodyParser(&body); err != nil {
-		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	// Refuse before a room is found or created for a user who may not play
+	if err := limitStore.CheckPlay(context.Background(), userID, body.BetAmount); err != nil {
+		if isLimitError(err) {
+			return fiber.NewError(http.StatusForbidden, err.Error())
+		}
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
 
-	room, err := roomStore.FindOrCreateRoom(context.Background(), body.BetAmount)
+	room, err := roomStore.FindOrCreateRoom(context.Background(), body.BetAmount, variant)
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
@@ -194,6 +241,18 @@ func FindOrCreateRoomHandler(c *fiber.Ctx) error {
 		if err.Error() == "user already in room" {
 			return fiber.NewError(http.StatusConflict, "User already joined this room")
 		}
+		if errors.Is(err, db.ErrInsufficientBalance) {
+			return fiber.NewError(http.StatusBadRequest, "Insufficient balance")
+		}
+		if errors.Is(err, db.ErrWalletFrozen) {
+			return fiber.NewError(http.StatusForbidden, "Wallet is frozen")
+		}
+		if isLimitError(err) {
+			return fiber.NewError(http.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, db.ErrRoomNotWaiting) {
+			return fiber.NewError(http.StatusConflict, err.Error())
+		}
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -217,6 +276,111 @@ func ForceStartCountdownHandler(c *fiber.Ctx) error {
 	return c.SendStatus(http.StatusNoContent)
 }
 
+// SetRoomRakeHandler overrides the house commission of a room that has not started.
+func SetRoomRakeHandler(c *fiber.Ctx) error {
+	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid room ID")
+	}
+	var body db.RakeConfig
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := body.Validate(); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	room, err := roomStore.SetRoomRake(context.Background(), roomID, body)
+	if errors.Is(err, db.ErrRoomNotWaiting) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(room)
+}
+
+// SetDrawIntervalHandler sets how many seconds pass between the draws of a room.
+func SetDrawIntervalHandler(c *fiber.Ctx) error {
+	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid room ID")
+	}
+	type req struct {
+		Seconds int `json:"seconds"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	if body.Seconds < 1 || body.Seconds > 300 {
+		return fiber.NewError(http.StatusBadRequest, "seconds must be between 1 and 300")
+	}
+	room, err := roomStore.SetDrawInterval(context.Background(), roomID, body.Seconds)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusNotFound, "Room not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(room)
+}
+
+// SetRoomPatternHandler sets the win pattern of a room that has not started.
+func SetRoomPatternHandler(c *fiber.Ctx) error {
+	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid room ID")
+	}
+	type req struct {
+		Name string `json:"name"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	room, err := roomStore.SetRoomPattern(context.Background(), roomID, body.Name)
+	if errors.Is(err, db.ErrPatternNotFound) {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, db.ErrRoomNotWaiting) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(room)
+}
+
+// SetRoomStagesHandler splits the pot of a room that has not started into
+// ordered prize stages, each won with its own pattern and paid its share.
+func SetRoomStagesHandler(c *fiber.Ctx) error {
+	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid room ID")
+	}
+	type req struct {
+		Stages []db.RoomStage `json:"stages"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := db.ValidateStages(body.Stages); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	room, err := roomStore.SetRoomStages(context.Background(), roomID, body.Stages)
+	if errors.Is(err, db.ErrPatternNotFound) {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, db.ErrRoomNotWaiting) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(room)
+}
+
 func ResetCountdownHandler(c *fiber.Ctx) error {
 	roomID, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
@@ -231,18 +395,25 @@ func ResetCountdownHandler(c *fiber.Ctx) error {
 }
 
 func RegisterRoomRoutes(router fiber.Router) {
-	router.Post("/rooms", CreateRoomHandler)
 	router.Post("/rooms/find-or-create", FindOrCreateRoomHandler)
 	router.Get("/rooms", ListRoomsHandler)
 	router.Get("/rooms/:id", GetRoomHandler)
 	router.Post("/rooms/:id/join", JoinRoomHandler)
 	router.Post("/rooms/:id/leave", LeaveRoomHandler)
-	router.Post("/rooms/:id/start", StartRoomHandler)
 	router.Get("/rooms/:id/players", GetRoomPlayersHandler)
 	router.Get("/rooms/:id/countdown", GetCountdownHandler)
+	router.Get("/rooms/:id/rules", GetRoomRulesHandler)
 	router.Get("/rooms/:id/cards", GetRoomCardsHandler)
-	router.Post("/rooms/:id/bet", PlaceBetHandler)
-	// Debug/admin endpoint
+}
+
+// RegisterRoomAdminRoutes registers the operator-only room tools under the admin group.
+func RegisterRoomAdminRoutes(router fiber.Router) {
+	router.Post("/rooms", CreateRoomHandler)
+	router.Post("/rooms/:id/start", StartRoomHandler)
 	router.Post("/rooms/:id/force-countdown", ForceStartCountdownHandler)
-	router.Post("/rooms/:id/reset-countdown", ResetCountdownHandler) // Admin tool
+	router.Post("/rooms/:id/reset-countdown", ResetCountdownHandler)
+	router.Put("/rooms/:id/rake", SetRoomRakeHandler)
+	router.Put("/rooms/:id/draw-interval", SetDrawIntervalHandler)
+	router.Put("/rooms/:id/pattern", SetRoomPatternHandler)
+	router.Put("/rooms/:id/stages", SetRoomStagesHandler)
 }
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"rockbingo/internal/db"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -28,11 +30,40 @@ func RegisterRoutes(app *fiber.App) {
 
 	api := app.Group("/api")
 
+	// Public routes must be registered before the auth middleware below.
+	RegisterHealthRoutes(api)
+	RegisterAuthRoutes(api)
+	RegisterServiceRoutes(api.Group("/service", RequireService))
+	RegisterPaymentRoutes(api)
+
+	api.Use(RequireAuth)
+
 	RegisterUserRoutes(api)
 	RegisterRoomRoutes(api)
 	RegisterSessionRoutes(api)
 	RegisterCardRoutes(api)
 	RegisterWalletRoutes(api)
+	RegisterWithdrawalRoutes(api)
+	RegisterPayoutMethodRoutes(api)
+	RegisterPromoRoutes(api)
+	RegisterReferralRoutes(api)
+	RegisterLimitRoutes(api)
+	RegisterPatternRoutes(api)
 	RegisterAuditRoutes(api)
-	RegisterHealthRoutes(api)
+
+	// Operator tools; every state-changing call is audited against the operator.
+	admin := api.Group("/admin", RequireRole(db.RoleOperator, db.RoleAdmin), auditAdminAction)
+	RegisterRoomAdminRoutes(admin)
+	RegisterSessionAdminRoutes(admin)
+	RegisterLifecycleAdminRoutes(admin)
+	RegisterLedgerAdminRoutes(admin)
+	RegisterTierAdminRoutes(admin)
+	RegisterPatternAdminRoutes(admin)
+	RegisterWithdrawalAdminRoutes(admin)
+	RegisterPaymentAdminRoutes(admin)
+	RegisterPayoutMethodAdminRoutes(admin)
+	RegisterPromoAdminRoutes(admin)
+
+	// User management is reserved to admins.
+	RegisterAdminUserRoutes(admin.Group("/users", RequireRole(db.RoleAdmin)))
 }
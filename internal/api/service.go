@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"rockbingo/internal/db"
+	"rockbingo/internal/serviceauth"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// serviceTelegramIDKey is the fiber.Ctx locals key holding the Telegram user a
+// signed service request acts for.
+const serviceTelegramIDKey = "serviceTelegramID"
+
+var (
+	serviceSecret []byte
+	serviceNonces *db.ServiceNonceStore
+)
+
+// InitServiceAuth sets the secret shared with the Telegram bot for signed
+// service requests and where the nonces of accepted requests are kept.
+func InitServiceAuth(secret string, nonces *db.ServiceNonceStore) {
+	serviceSecret = []byte(secret)
+	serviceNonces = nonces
+}
+
+// verifyServiceRequest checks the service signature headers, refuses a nonce
+// that was used before and returns the Telegram user ID the request acts for.
+// The signature covers the URL as routed here, after any proxy prefix has
+// been stripped (see serviceauth.Sign).
+func verifyServiceRequest(c *fiber.Ctx) (int64, error) {
+	telegramIDStr := c.Get(serviceauth.HeaderTelegramID)
+	nonce := c.Get(serviceauth.HeaderNonce)
+	expiresAt, err := serviceauth.Verify(serviceSecret, c.Method(), c.OriginalURL(),
+		c.Get(serviceauth.HeaderTimestamp), nonce, telegramIDStr, c.Body(), c.Get(serviceauth.HeaderSignature))
+	if err != nil {
+		return 0, fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	telegramID, err := strconv.ParseInt(telegramIDStr, 10, 64)
+	if err != nil || telegramID == 0 {
+		return 0, fiber.NewError(http.StatusBadRequest, "Invalid Telegram ID")
+	}
+	fresh, err := serviceNonces.Use(context.Background(), nonce, expiresAt)
+	if err != nil {
+		return 0, fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if !fresh {
+		return 0, fiber.NewError(http.StatusUnauthorized, "service request already used")
+	}
+	return telegramID, nil
+}
+
+// authenticateService resolves the user a signed service request acts for.
+// Users must have been synced through /service/users/sync first.
+func authenticateService(c *fiber.Ctx) error {
+	telegramID, err := verifyServiceRequest(c)
+	if err != nil {
+		return err
+	}
+	user, err := userStore.GetByTelegramID(context.Background(), telegramID)
+	if err == sql.ErrNoRows {
+		return fiber.NewError(http.StatusUnauthorized, "User not registered")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	c.Locals(userIDKey, user.ID)
+	c.Locals(userRoleKey, user.Role)
+	return c.Next()
+}
+
+// RequireService only admits requests signed with the bot's service secret.
+func RequireService(c *fiber.Ctx) error {
+	telegramID, err := verifyServiceRequest(c)
+	if err != nil {
+		return err
+	}
+	c.Locals(serviceTelegramIDKey, telegramID)
+	return c.Next()
+}
+
+// SyncServiceUserHandler registers or refreshes the Telegram user the bot acts for.
+func SyncServiceUserHandler(c *fiber.Ctx) error {
+	telegramID, _ := c.Locals(serviceTelegramIDKey).(int64)
+	type req struct {
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	user, err := userStore.FindOrCreateByTelegram(context.Background(), telegramID, body.Username, body.FirstName, body.LastName)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(user)
+}
+
+// RegisterServiceRoutes registers bot-only routes on the /service group.
+func RegisterServiceRoutes(router fiber.Router) {
+	router.Post("/users/sync", SyncServiceUserHandler)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"rockbingo/internal/db"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var tierStore *db.TierStore
+
+func InitTierHandlers(store *db.TierStore) {
+	tierStore = store
+}
+
+func ListTiersHandler(c *fiber.Ctx) error {
+	tiers, err := tierStore.ListTiers(context.Background())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(tiers)
+}
+
+// UpsertTierHandler sets the settings of the tier for a bet amount. They
+// apply to rooms created from then on.
+func UpsertTierHandler(c *fiber.Ctx) error {
+	type req struct {
+		BetAmount      db.Money `json:"bet_amount"`
+		NoWinnerPolicy string   `json:"no_winner_policy"`
+		db.RakeConfig
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	if !body.BetAmount.IsPositive() {
+		return fiber.NewError(http.StatusBadRequest, "Bet amount must be positive")
+	}
+	if body.NoWinnerPolicy == "" {
+		body.NoWinnerPolicy = db.NoWinnerRefund
+	}
+	if !db.ValidNoWinnerPolicy(body.NoWinnerPolicy) {
+		return fiber.NewError(http.StatusBadRequest, "Invalid no_winner_policy")
+	}
+	if err := body.RakeConfig.Validate(); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	tier, err := tierStore.UpsertTier(context.Background(), body.BetAmount, body.NoWinnerPolicy, body.RakeConfig)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(tier)
+}
+
+// RegisterTierAdminRoutes registers room tier routes on the /admin group.
+func RegisterTierAdminRoutes(router fiber.Router) {
+	router.Get("/tiers", ListTiersHandler)
+	router.Put("/tiers", UpsertTierHandler)
+}
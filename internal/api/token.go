@@ -0,0 +1,95 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+var tokenSecret []byte
+
+// tokenClaims is the payload of an API session token. Version must match
+// users.token_version, so bumping that column revokes every token of the user.
+type tokenClaims struct {
+	UserID    int64  `json:"uid"`
+	Version   int    `json:"ver"`
+	Type      string `json:"typ"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// signToken encodes claims as base64url(payload) "." base64url(HMAC-SHA256).
+func signToken(claims tokenClaims) string {
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, tokenSecret)
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseToken verifies the signature, type and expiry of a token. The caller
+// still has to check the version against the user's current token_version.
+func parseToken(token, tokenType string) (*tokenClaims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.New("malformed token")
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return nil, errors.New("malformed token")
+	}
+	mac := hmac.New(sha256.New, tokenSecret)
+	mac.Write([]byte(encoded))
+	if !hmac.Equal(mac.Sum(nil), got) {
+		return nil, errors.New("invalid token signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("malformed token")
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("malformed token")
+	}
+	if claims.Type != tokenType {
+		return nil, errors.New("wrong token type")
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("token expired")
+	}
+	return &claims, nil
+}
+
+// tokenPair is the response body of the login and refresh endpoints.
+type tokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func issueTokens(userID int64, version int) tokenPair {
+	now := time.Now()
+	accessExp := now.Add(accessTokenTTL)
+	return tokenPair{
+		AccessToken: signToken(tokenClaims{
+			UserID: userID, Version: version, Type: tokenTypeAccess, ExpiresAt: accessExp.Unix(),
+		}),
+		RefreshToken: signToken(tokenClaims{
+			UserID: userID, Version: version, Type: tokenTypeRefresh, ExpiresAt: now.Add(refreshTokenTTL).Unix(),
+		}),
+		TokenType: "Bearer",
+		ExpiresAt: accessExp,
+	}
+}
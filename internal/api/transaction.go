@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"rockbingo/internal/db"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultTransactionPage = 50
+	maxTransactionPage     = 200
+)
+
+// transactionFilter reads the transaction filters shared by the history and
+// the statement export: type (comma-separated), from and to (dates, both
+// inclusive, or RFC 3339 times), room_id and session_id.
+func transactionFilter(c *fiber.Ctx) (db.TransactionFilter, error) {
+	var filter db.TransactionFilter
+	var err error
+	if types := c.Query("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			t = strings.TrimSpace(t)
+			if !db.ValidTransactionType(t) {
+				return filter, fiber.NewError(http.StatusBadRequest, fmt.Sprintf("Invalid transaction type %q", t))
+			}
+			filter.Types = append(filter.Types, t)
+		}
+	}
+	if filter.From, err = queryTime(c, "from", false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(c, "to", true); err != nil {
+		return filter, err
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fiber.NewError(http.StatusBadRequest, "from must be before to")
+	}
+	if filter.RoomID, err = queryID(c, "room_id"); err != nil {
+		return filter, err
+	}
+	if filter.SessionID, err = queryID(c, "session_id"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// queryTime parses an optional date or RFC 3339 time. A date ending a range
+// includes that whole day.
+func queryTime(c *fiber.Ctx, name string, end bool) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return nil, fiber.NewError(http.StatusBadRequest, fmt.Sprintf("Invalid %s date", name))
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// queryID parses an optional positive ID, returning 0 when absent
+func queryID(c *fiber.Ctx, name string) (int64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fiber.NewError(http.StatusBadRequest, fmt.Sprintf("Invalid %s", name))
+	}
+	return id, nil
+}
+
+// GetTransactionsHandler pages through the caller's transactions, newest
+// first. Pass the returned next_cursor as ?cursor= for the next page; it is
+// null on the last page.
+func GetTransactionsHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	filter, err := transactionFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.Limit = defaultTransactionPage
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxTransactionPage {
+			return fiber.NewError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxTransactionPage))
+		}
+		filter.Limit = limit
+	}
+	if filter.Before, err = queryID(c, "cursor"); err != nil {
+		return err
+	}
+
+	txs, err := walletStore.GetTransactions(context.Background(), userID, filter)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	var nextCursor *int64
+	if len(txs) == filter.Limit {
+		nextCursor = &txs[len(txs)-1].ID
+	}
+	if txs == nil {
+		txs = []db.Transaction{}
+	}
+	return c.JSON(fiber.Map{
+		"transactions": txs,
+		"next_cursor":  nextCursor,
+	})
+}
+
+// ExportTransactionsHandler returns every transaction matching the filters
+// as a CSV statement.
+func ExportTransactionsHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	filter, err := transactionFilter(c)
+	if err != nil {
+		return err
+	}
+	txs, err := walletStore.GetTransactions(context.Background(), userID, filter)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "date", "type", "balance", "direction", "amount", "currency", "room_id", "session_id", "deposit_id", "withdrawal_id"})
+	for _, t := range txs {
+		w.Write([]string{
+			strconv.FormatInt(t.ID, 10),
+			t.CreatedAt.UTC().Format(time.RFC3339),
+			t.Type,
+			t.Balance,
+			t.Direction,
+			t.Amount.Decimal(),
+			string(t.Amount.Cur()),
+			csvID(t.RoomID),
+			csvID(t.SessionID),
+			csvID(t.DepositID),
+			csvID(t.WithdrawalID),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="statement-%d.csv"`, userID))
+	return c.Send(buf.Bytes())
+}
+
+func csvID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
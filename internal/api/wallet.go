@@ -2,8 +2,14 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"rockbingo/internal/db"
+	"rockbingo/internal/payment"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -26,64 +32,110 @@ func GetWalletHandler(c *fiber.Ctx) error {
 	return c.JSON(wallet)
 }
 
-func GetTransactionsHandler(c *fiber.Ctx) error {
+// DepositHandler opens a checkout with the payment provider for a pending
+// deposit. The wallet is credited only when the provider confirms the
+// payment through its webhook.
+func DepositHandler(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
-	txs, err := walletStore.GetTransactions(context.Background(), userID)
+	type req struct {
+		Amount         db.Money `json:"amount"`
+		Currency       string   `json:"currency"`
+		TransactionRef string   `json:"transaction_ref"`
+	}
+	var body req
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	}
+	amount, err := requestAmount(body.Amount, body.Currency)
+	if err != nil {
+		return err
+	}
+	if body.TransactionRef == "" {
+		body.TransactionRef = fmt.Sprintf("deposit-%d-%d", userID, time.Now().UnixNano())
+	}
+	ctx := context.Background()
+	dep, err := walletStore.CreateDeposit(ctx, userID, amount, body.TransactionRef, paymentProvider.Name())
+	if errors.Is(err, db.ErrDuplicateTransactionRef) {
+		return fiber.NewError(http.StatusConflict, err.Error())
+	}
+	if isLimitError(err) {
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	checkout, err := paymentProvider.CreateCheckout(ctx, payment.CheckoutRequest{
+		Reference:   dep.TransactionRef,
+		Amount:      amount,
+		Description: "Rock Bingo deposit",
+	})
+	if err != nil {
+		log.Printf("[DepositHandler] Checkout for deposit %s failed: %v", dep.TransactionRef, err)
+		// A deposit left pending would keep counting against the deposit limits
+		if _, serr := walletStore.SettleDeposit(ctx, paymentProvider.Name(), dep.TransactionRef, db.DepositFailed, amount); serr != nil {
+			log.Printf("[DepositHandler] Failed to mark deposit %s failed: %v", dep.TransactionRef, serr)
+		}
+		return fiber.NewError(http.StatusBadGateway, "Payment provider unavailable")
+	}
+	dep, err = walletStore.SetDepositCheckout(ctx, dep.ID, checkout.ProviderRef, checkout.URL)
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
-	return c.JSON(txs)
+	return c.Status(http.StatusCreated).JSON(dep)
 }
 
-func DepositHandler(c *fiber.Ctx) error {
+// GetDepositHandler returns one of the caller's deposits with the status the
+// payment provider reports for it. Only the webhook settles a deposit.
+func GetDepositHandler(c *fiber.Ctx) error {
 	userID, err := getUserID(c)
 	if err != nil {
 		return err
 	}
-	type req struct {
-		Amount         float64 `json:"amount"`
-		Currency       string  `json:"currency"`
-		TransactionRef string  `json:"transaction_ref"`
-		Status         string  `json:"status"`
-	}
-	var body req
-	if err := c.BodyParser(&body); err != nil {
-		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+	ctx := context.Background()
+	dep, err := walletStore.GetDepositByRef(ctx, c.Params("ref"))
+	if err == sql.ErrNoRows || (err == nil && dep.UserID != userID) {
+		return fiber.NewError(http.StatusNotFound, "Deposit not found")
 	}
-	dep, err := walletStore.CreateDeposit(context.Background(), userID, body.Amount, body.Currency, body.TransactionRef, body.Status)
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
-	// Auto-credit wallet if status is completed
-	if body.Status == "completed" {
-		// Update wallet balance
-		_, werr := walletStore.DB.ExecContext(context.Background(), `
-			UPDATE wallets SET balance = balance + $1, updated_at = NOW() WHERE user_id = $2
-		`, body.Amount, userID)
-		if werr != nil {
-			return fiber.NewError(http.StatusInternalServerError, werr.Error())
+	var providerStatus payment.Status
+	if dep.ProviderRef != nil && dep.Provider != nil && *dep.Provider == paymentProvider.Name() {
+		providerStatus, err = paymentProvider.Status(ctx, *dep.ProviderRef)
+		if err != nil {
+			log.Printf("[GetDepositHandler] Status of deposit %s: %v", dep.TransactionRef, err)
 		}
+	}
+	return c.JSON(fiber.Map{"deposit": dep, "provider_status": providerStatus})
+}
 
-		// Create transaction record
-		_, terr := walletStore.DB.ExecContext(context.Background(), `
-			INSERT INTO transactions (user_id, type, amount, created_at)
-			VALUES ($1, 'deposit', $2, NOW())
-		`, userID, body.Amount)
-		if terr != nil {
-			return fiber.NewError(http.StatusInternalServerError, terr.Error())
-		}
+// requestAmount validates a positive request amount and tags it with the
+// requested currency, which defaults to db.DefaultCurrency.
+func requestAmount(amount db.Money, currency string) (db.Money, error) {
+	cur := db.DefaultCurrency
+	if currency != "" {
+		cur = db.Currency(currency)
+	}
+	if !db.ValidCurrency(cur) {
+		return db.Money{}, fiber.NewError(http.StatusBadRequest, "Unsupported currency")
 	}
-	return c.JSON(dep)
+	if !amount.IsPositive() {
+		return db.Money{}, fiber.NewError(http.StatusBadRequest, "Amount must be positive")
+	}
+	return db.NewMoney(amount.Minor, cur), nil
 }
 
 func RegisterWalletRoutes(router fiber.Router) {
 	router.Get("/wallet", GetWalletHandler)
 	router.Get("/transactions", GetTransactionsHandler)
-	router.Post("/deposit", DepositHandler)
-	router.Post("/withdraw", WithdrawHandler)
+	router.Get("/transactions/export", ExportTransactionsHandler)
+	router.Post("/deposit", Idempotent, DepositHandler)
+	router.Get("/deposits/:ref", GetDepositHandler)
+	router.Post("/withdraw", Idempotent, WithdrawHandler)
 }
 
 func WithdrawHandler(c *fiber.Ctx) error {
@@ -92,36 +144,31 @@ func WithdrawHandler(c *fiber.Ctx) error {
 		return err
 	}
 	type req struct {
-		Amount      float64 `json:"amount"`
-		Currency    string  `json:"currency"`
-		Destination string  `json:"destination"`
+		Amount         db.Money `json:"amount"`
+		Currency       string   `json:"currency"`
+		PayoutMethodID int64    `json:"payout_method_id"`
 	}
 	var body req
 	if err := c.BodyParser(&body); err != nil {
 		return fiber.NewError(http.StatusBadRequest, "Invalid request body")
 	}
-	// Check balance
-	wallet, err := walletStore.GetWallet(context.Background(), userID)
+	amount, err := requestAmount(body.Amount, body.Currency)
 	if err != nil {
-		return fiber.NewError(http.StatusNotFound, "Wallet not found")
+		return err
 	}
-	if wallet.Balance < body.Amount {
+	// The amount is held until an operator reviews the request
+	wr, err := withdrawalStore.CreateWithdrawal(context.Background(), userID, amount, body.PayoutMethodID)
+	if errors.Is(err, db.ErrInsufficientBalance) {
 		return fiber.NewError(http.StatusBadRequest, "Insufficient balance")
 	}
-	// Deduct balance
-	_, werr := walletStore.DB.ExecContext(context.Background(), `
-		UPDATE wallets SET balance = balance - $1, updated_at = NOW() WHERE user_id = $2
-	`, body.Amount, userID)
-	if werr != nil {
-		return fiber.NewError(http.StatusInternalServerError, werr.Error())
-	}
-	// Create transaction
-	_, terr := walletStore.DB.ExecContext(context.Background(), `
-		INSERT INTO transactions (user_id, type, amount, created_at)
-		VALUES ($1, 'withdraw', $2, NOW())
-	`, userID, body.Amount)
-	if terr != nil {
-		return fiber.NewError(http.StatusInternalServerError, terr.Error())
-	}
-	return c.JSON(fiber.Map{"status": "success", "amount": body.Amount, "currency": body.Currency})
+	if errors.Is(err, db.ErrWalletFrozen) {
+		return fiber.NewError(http.StatusForbidden, "Wallet is frozen")
+	}
+	if errors.Is(err, db.ErrPayoutMethodNotFound) || errors.Is(err, db.ErrPayoutMethodUnverified) {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(wr)
 }
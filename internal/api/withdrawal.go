@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var withdrawalStore *db.WithdrawalStore
+
+func InitWithdrawalHandlers(store *db.WithdrawalStore) {
+	withdrawalStore = store
+}
+
+// ListMyWithdrawalsHandler lists the caller's withdrawal requests, newest first.
+func ListMyWithdrawalsHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	reqs, err := withdrawalStore.ListUserWithdrawals(context.Background(), userID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(reqs)
+}
+
+func GetMyWithdrawalHandler(c *fiber.Ctx) error {
+	userID, err := getUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "Invalid withdrawal ID")
+	}
+	req, err := withdrawalStore.GetWithdrawal(context.Background(), id)
+	if err == sql.ErrNoRows || (err == nil && req.UserID != userID) {
+		return fiber.NewError(http.StatusNotFound, "Withdrawal not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(req)
+}
+
+// ListWithdrawalsHandler lists withdrawal requests for review, oldest first,
+// optionally filtered by ?status=.
+func ListWithdrawalsHandler(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if status != "" && !db.ValidWithdrawalStatus(status) {
+		return fiber.NewError(http.StatusBadRequest, "Invalid status")
+	}
+	reqs, err := withdrawalStore.ListWithdrawals(context.Background(), status)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(reqs)
+}
+
+// transitionWithdrawalHandler returns a handler moving a withdrawal request
+// to status, with an optional {"note": "..."} from the operator.
+func transitionWithdrawalHandler(status string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		operatorID, err := getUserID(c)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return fiber.NewError(http.StatusBadRequest, "Invalid withdrawal ID")
+		}
+		type req struct {
+			Note string `json:"note"`
+		}
+		var body req
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&body); err != nil {
+				return fiber.NewError(http.StatusBadRequest, "Invalid request body")
+			}
+		}
+		wr, err := withdrawalStore.Transition(context.Background(), id, status, operatorID, body.Note)
+		if err == sql.ErrNoRows {
+			return fiber.NewError(http.StatusNotFound, "Withdrawal not found")
+		}
+		if errors.Is(err, db.ErrInvalidWithdrawalTransition) {
+			return fiber.NewError(http.StatusConflict, err.Error())
+		}
+		if err != nil {
+			return fiber.NewError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(wr)
+	}
+}
+
+func RegisterWithdrawalRoutes(router fiber.Router) {
+	router.Get("/withdrawals", ListMyWithdrawalsHandler)
+	router.Get("/withdrawals/:id", GetMyWithdrawalHandler)
+}
+
+// RegisterWithdrawalAdminRoutes registers withdrawal review routes on the /admin group.
+func RegisterWithdrawalAdminRoutes(router fiber.Router) {
+	router.Get("/withdrawals", ListWithdrawalsHandler)
+	router.Post("/withdrawals/:id/approve", transitionWithdrawalHandler(db.WithdrawalApproved))
+	router.Post("/withdrawals/:id/reject", transitionWithdrawalHandler(db.WithdrawalRejected))
+	router.Post("/withdrawals/:id/mark-paid", transitionWithdrawalHandler(db.WithdrawalPaid))
+	router.Post("/withdrawals/:id/mark-failed", transitionWithdrawalHandler(db.WithdrawalFailed))
+}
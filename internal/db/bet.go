@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Bet statuses
+const (
+	BetHeld     = "held"
+	BetRefunded = "refunded"
+	BetSettled  = "settled"
+)
+
+// holdStake debits the room's bet amount from the user's wallet into the room
+// escrow, once per player and room, spending bonus funds before cash. The
+// caller must hold the room row lock. Returns ErrInsufficientBalance if the
+// wallet cannot cover the bet, ErrRoomNotWaiting once the room's game has
+// started, and the error of checkPlayLimits if the user's responsible gaming
+// limits do not allow it.
+func holdStake(ctx context.Context, tx *sqlx.Tx, room *BingoRoom, userID int64, cardID *int64) error {
+	if room.Status != RoomWaiting {
+		return ErrRoomNotWaiting
+	}
+	var held bool
+	err := tx.GetContext(ctx, &held, `
+		SELECT EXISTS (SELECT 1 FROM user_bets WHERE room_id = $1 AND user_id = $2 AND status = 'held')
+	`, room.ID, userID)
+	if err != nil {
+		return err
+	}
+	if held {
+		if cardID != nil {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE user_bets SET bingo_card_id = $3
+				WHERE room_id = $1 AND user_id = $2 AND status = 'held' AND bingo_card_id IS NULL
+			`, room.ID, userID, *cardID)
+		}
+		return err
+	}
+
+	if err := checkStakeLimits(ctx, tx, userID, room.BetAmount); err != nil {
+		return err
+	}
+	fromBonus := NewMoney(0, room.BetAmount.Cur())
+	if room.BetAmount.IsPositive() {
+		var bonus Money
+		err = tx.GetContext(ctx, &bonus, `SELECT bonus_balance FROM wallets WHERE user_id = $1 FOR UPDATE`, userID)
+		if err == sql.ErrNoRows {
+			return ErrInsufficientBalance
+		}
+		if err != nil {
+			return err
+		}
+		fromBonus = bonus
+		if fromBonus.Cmp(room.BetAmount) > 0 {
+			fromBonus = room.BetAmount
+		}
+		_, err = postJournal(ctx, tx, Journal{
+			Kind:   JournalBet,
+			Memo:   fmt.Sprintf("room %d", room.ID),
+			Source: Source{RoomID: room.ID},
+			Postings: []Posting{
+				{Account: UserBonusAccount(userID), Amount: fromBonus.Neg()},
+				{Account: UserWalletAccount(userID), Amount: room.BetAmount.Sub(fromBonus).Neg()},
+				{Account: RoomEscrowAccount(room.ID), Amount: room.BetAmount},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_bets (user_id, room_id, bingo_card_id, bet_amount, bonus_amount, status)
+		VALUES ($1, $2, $3, $4, $5, 'held')
+	`, userID, room.ID, cardID, room.BetAmount, fromBonus)
+	return err
+}
+
+// refundStake returns the user's held stake in a room from the escrow
+func refundStake(ctx context.Context, tx *sqlx.Tx, roomID, userID int64) error {
+	var bets []UserBet
+	err := tx.SelectContext(ctx, &bets, `
+		UPDATE user_bets SET status = 'refunded', settled_at = NOW()
+		WHERE room_id = $1 AND user_id = $2 AND status = 'held'
+		RETURNING *
+	`, roomID, userID)
+	if err != nil {
+		return err
+	}
+	return refundBets(ctx, tx, roomID, bets)
+}
+
+// refundBets pays bets back from the room escrow in one journal. The part of
+// a stake paid from bonus funds goes back to the bonus account while the
+// user still has a bonus to wager, and to cash otherwise.
+func refundBets(ctx context.Context, tx *sqlx.Tx, roomID int64, bets []UserBet) error {
+	j := Journal{Kind: JournalRefund, Memo: fmt.Sprintf("room %d", roomID), Source: Source{RoomID: roomID}}
+	total := NewMoney(0, DefaultCurrency)
+	for _, bet := range bets {
+		if !bet.BetAmount.IsPositive() {
+			continue
+		}
+		cash := bet.BetAmount
+		if bet.BonusAmount.IsPositive() {
+			wagering, err := hasActiveBonus(ctx, tx, bet.UserID)
+			if err != nil {
+				return err
+			}
+			if wagering {
+				cash = cash.Sub(bet.BonusAmount)
+				j.Postings = append(j.Postings, Posting{Account: UserBonusAccount(bet.UserID), Amount: bet.BonusAmount})
+			}
+		}
+		j.Postings = append(j.Postings, Posting{Account: UserWalletAccount(bet.UserID), Amount: cash})
+		total = total.Add(bet.BetAmount)
+	}
+	if total.IsZero() {
+		return nil
+	}
+	j.Postings = append(j.Postings, Posting{Account: RoomEscrowAccount(roomID), Amount: total.Neg()})
+	_, err := postJournal(ctx, tx, j)
+	return err
+}
+
+// closeStakes moves every held stake in a room to status and returns the
+// bets with their total, which is what the room escrow holds for them
+func closeStakes(ctx context.Context, tx *sqlx.Tx, roomID int64, status string) ([]UserBet, Money, error) {
+	var bets []UserBet
+	err := tx.SelectContext(ctx, &bets, `
+		UPDATE user_bets SET status = $2, settled_at = NOW()
+		WHERE room_id = $1 AND status = 'held'
+		RETURNING *
+	`, roomID, status)
+	if err != nil {
+		return nil, Money{}, err
+	}
+	total := NewMoney(0, DefaultCurrency)
+	for _, bet := range bets {
+		total = total.Add(bet.BetAmount)
+	}
+	// Only stakes that were played count towards wagering requirements and referrals
+	if status == BetSettled {
+		if err := applyWagering(ctx, tx, bets); err != nil {
+			return nil, Money{}, err
+		}
+		if err := attributeWagers(ctx, tx, bets); err != nil {
+			return nil, Money{}, err
+		}
+	}
+	return bets, total, nil
+}
+
+// settleStakes marks every held stake in a room as settled and returns their total
+func settleStakes(ctx context.Context, tx *sqlx.Tx, roomID int64) (Money, error) {
+	_, total, err := closeStakes(ctx, tx, roomID, BetSettled)
+	return total, err
+}
+
+// recordStakeTransactions lists what happened to each player's stake in
+// their transactions when it left the escrow for a non-wallet account
+func recordStakeTransactions(ctx context.Context, tx *sqlx.Tx, journalID int64, kind string, bets []UserBet, src Source) error {
+	for _, bet := range bets {
+		if err := recordTransaction(ctx, tx, bet.UserID, kind, BalanceCash, DirectionNone, bet.BetAmount, journalID, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,11 +2,16 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"rockbingo/internal/game"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrCardAlreadySelected is returned when a player who has a card in a room selects another
+var ErrCardAlreadySelected = errors.New("you already have a card in this room")
+
 type CardStore struct {
 	DB *sqlx.DB
 }
@@ -15,24 +20,22 @@ func NewCardStore(db *sqlx.DB) *CardStore {
 	return &CardStore{DB: db}
 }
 
-// Create a new card for a user in a room
-func (s *CardStore) CreateCard(ctx context.Context, userID, roomID int64) (*BingoCard, error) {
-	card := game.NewCard()
-	cardData, err := card.ToJSON()
-	if err != nil {
-		return nil, err
+// CardVariant returns the variant of the cards the room is played with
+func (r *BingoRoom) CardVariant() (game.Variant, error) {
+	v, ok := game.LookupVariant(r.Variant)
+	if !ok {
+		return nil, fmt.Errorf("room %d has unknown variant %q", r.ID, r.Variant)
 	}
+	return v, nil
+}
 
-	var newCard BingoCard
-	err = s.DB.GetContext(ctx, &newCard, `
-		INSERT INTO bingo_cards (user_id, room_id, card_data, is_winner)
-		VALUES ($1, $2, $3, false)
-		RETURNING *
-	`, userID, roomID, cardData)
-	if err != nil {
+// roomCardVariant returns the variant of the cards of a room
+func roomCardVariant(ctx context.Context, q sqlx.QueryerContext, roomID int64) (game.Variant, error) {
+	var room BingoRoom
+	if err := sqlx.GetContext(ctx, q, &room, `SELECT * FROM bingo_rooms WHERE id = $1`, roomID); err != nil {
 		return nil, err
 	}
-	return &newCard, nil
+	return room.CardVariant()
 }
 
 // Get card by ID
@@ -61,8 +64,12 @@ func (s *CardStore) GetAllCardsInRoom(ctx context.Context, roomID int64) ([]Bing
 
 // Initialize available cards for a room
 func (s *CardStore) InitializeAvailableCards(ctx context.Context, roomID int64) error {
-	// Generate 100 available cards
-	cards := game.GenerateAvailableCards()
+	variant, err := roomCardVariant(ctx, s.DB, roomID)
+	if err != nil {
+		return err
+	}
+	// Generate 100 available cards, or whole strips of 90-ball tickets
+	cards := game.GenerateAvailableCards(variant)
 
 	// Insert all cards into the database
 	for i, card := range cards {
@@ -132,10 +139,35 @@ func (s *CardStore) GetAvailableCards(ctx context.Context, roomID int64) ([]Avai
 	return cards, err
 }
 
-// Select a card for a user
+// Select a card for a user, taking the room's stake into escrow if the user
+// has not staked in this room yet. A stake buys one card, so a user who has
+// a card in the room gets ErrCardAlreadySelected.
 func (s *CardStore) SelectCard(ctx context.Context, roomID int64, cardNumber int, userID int64) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var room BingoRoom
+	err = tx.GetContext(ctx, &room, `SELECT * FROM bingo_rooms WHERE id = $1 FOR UPDATE`, roomID)
+	if err != nil {
+		return err
+	}
+
+	var selected bool
+	err = tx.GetContext(ctx, &selected, `
+		SELECT EXISTS (SELECT 1 FROM available_cards WHERE room_id = $1 AND selected_by_user_id = $2)
+	`, roomID, userID)
+	if err != nil {
+		return err
+	}
+	if selected {
+		return ErrCardAlreadySelected
+	}
+
 	// Select the card in available_cards
-	res, err := s.DB.ExecContext(ctx, `
+	res, err := tx.ExecContext(ctx, `
 		UPDATE available_cards 
 		SET is_selected = true, selected_by_user_id = $1
 		WHERE room_id = $2 AND card_number = $3 AND is_selected = false
@@ -154,33 +186,21 @@ func (s *CardStore) SelectCard(ctx context.Context, roomID int64, cardNumber int
 		return nil
 	}
 
-	// Check if bingo_cards already has a card for this user/room
-	var count int
-	err = s.DB.GetContext(ctx, &count, `
-		SELECT COUNT(*) FROM bingo_cards WHERE user_id = $1 AND room_id = $2
-	`, userID, roomID)
+	// Copy the card to bingo_cards; the user's stake is tied to it
+	var cardID int64
+	err = tx.GetContext(ctx, &cardID, `
+		INSERT INTO bingo_cards (user_id, room_id, card_data, is_winner)
+		SELECT $1, $2, card_data, false FROM available_cards WHERE room_id = $2 AND card_number = $3
+		RETURNING id
+	`, userID, roomID, cardNumber)
 	if err != nil {
 		return err
 	}
-	if count == 0 {
-		// Get the card data from available_cards
-		var cardData []byte
-		err = s.DB.GetContext(ctx, &cardData, `
-			SELECT card_data FROM available_cards WHERE room_id = $1 AND card_number = $2
-		`, roomID, cardNumber)
-		if err != nil {
-			return err
-		}
-		// Insert into bingo_cards
-		_, err = s.DB.ExecContext(ctx, `
-			INSERT INTO bingo_cards (user_id, room_id, card_data, is_winner)
-			VALUES ($1, $2, $3, false)
-		`, userID, roomID, cardData)
-		if err != nil {
-			return err
-		}
+
+	if err := holdStake(ctx, tx, &room, userID, &cardID); err != nil {
+		return err
 	}
-	return nil
+	return tx.Commit()
 }
 
 // Get user's selected card
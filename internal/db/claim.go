@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrClaimWindowOpen is returned when drawing while bingo claims are being collected
+	ErrClaimWindowOpen = errors.New("bingo has been called; no more numbers are drawn while claims are collected")
+	// ErrClaimWindowClosed is returned for a claim made after the claim window has passed
+	ErrClaimWindowClosed = errors.New("the claim window for this session has closed")
+	// ErrCardNotStaked is returned for a claim on a card no stake in the game paid for
+	ErrCardNotStaked = errors.New("this card was not bought for the game")
+	// ErrLateClaim is returned for a card that had bingo before the latest number was drawn
+	ErrLateClaim = errors.New("bingo must be claimed on the number that completes it")
+	// ErrSessionEnded is returned when drawing for a session that is over
+	ErrSessionEnded = errors.New("session has ended")
+	// ErrDrawNotDue is returned for a scheduled draw that is not due yet
+	ErrDrawNotDue = errors.New("no draw is due for this session")
+)
+
+// SettleClaims pays out the live stage of a session whose claim window has
+// passed. Sessions are settled lazily, so it is called before a session or
+// its winners are read. It does nothing for a session that is not due.
+func (s *SessionStore) SettleClaims(ctx context.Context, sessionID int64) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var session GameSession
+	err = tx.GetContext(ctx, &session, `SELECT * FROM game_sessions WHERE id = $1 FOR UPDATE`, sessionID)
+	if err != nil {
+		return err
+	}
+	if !claimsDue(&session) {
+		return nil
+	}
+	if _, err := settleClaims(ctx, tx, &session); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// payoutPostings credits a player's winnings from a session. While the
+// player has a bonus to wager, the part of the winnings their stake paid for
+// with bonus funds goes to their bonus account and stays subject to the
+// wagering requirement; the rest is cash.
+func payoutPostings(ctx context.Context, tx *sqlx.Tx, session *GameSession, userID int64, amount Money) ([]Posting, error) {
+	cash := []Posting{{Account: UserWalletAccount(userID), Amount: amount}}
+	wagering, err := hasActiveBonus(ctx, tx, userID)
+	if err != nil || !wagering {
+		return cash, err
+	}
+	var stake struct {
+		Bet   Money `db:"bet"`
+		Bonus Money `db:"bonus"`
+	}
+	err = tx.GetContext(ctx, &stake, `
+		SELECT COALESCE(SUM(bet_amount), 0) AS bet, COALESCE(SUM(bonus_amount), 0) AS bonus
+		FROM user_bets
+		WHERE room_id = $1 AND user_id = $2 AND status = 'settled' AND settled_at >= $3
+	`, session.RoomID, userID, session.SessionStartTime)
+	if err != nil {
+		return nil, err
+	}
+	if !stake.Bet.IsPositive() || !stake.Bonus.IsPositive() {
+		return cash, nil
+	}
+	bonus := NewMoney(amount.Minor*stake.Bonus.Minor/stake.Bet.Minor, amount.Cur())
+	return []Posting{
+		{Account: UserBonusAccount(userID), Amount: bonus},
+		{Account: UserWalletAccount(userID), Amount: amount.Sub(bonus)},
+	}, nil
+}
+
+// claimsDue reports whether a session's claim window has passed without it being settled
+func claimsDue(session *GameSession) bool {
+	return session.Status == "active" && session.ClaimDeadline != nil && !time.Now().Before(*session.ClaimDeadline)
+}
+
+// settleClaims pays the live prize stage of a session to every claim made
+// in its claim window and returns whether the session is over. Stakes held
+// since the last stage join the pot first. A stage wins its share of the
+// whole pot, the final stage what is left of it. The house rake on the pot
+// is taken stage by stage from the prizes (see StageRake); the rest is split
+// evenly, the minor units left over going one each to the earliest claims,
+// and the rake is allocated the same way. After any stage but the last, the
+// next one goes live and drawing resumes.
+func settleClaims(ctx context.Context, tx *sqlx.Tx, session *GameSession) (bool, error) {
+	var claims []BingoClaim
+	err := tx.SelectContext(ctx, &claims, `
+		SELECT * FROM bingo_claims WHERE session_id = $1 AND stage = $2 ORDER BY claimed_at, id
+	`, session.ID, session.CurrentStage)
+	if err != nil {
+		return false, err
+	}
+	if len(claims) == 0 {
+		return false, fmt.Errorf("session %d has a claim window but no claims", session.ID)
+	}
+
+	var room BingoRoom
+	err = tx.GetContext(ctx, &room, `SELECT * FROM bingo_rooms WHERE id = $1`, session.RoomID)
+	if err != nil {
+		return false, err
+	}
+	stages, err := sessionStages(session)
+	if err != nil {
+		return false, err
+	}
+	if session.CurrentStage < 1 || session.CurrentStage > len(stages) {
+		return false, fmt.Errorf("session %d has no stage %d", session.ID, session.CurrentStage)
+	}
+	stage := stages[session.CurrentStage-1]
+	final := session.CurrentStage == len(stages)
+
+	// The pot is every stake settled into the session plus any pot carried over
+	stakes, err := settleStakes(ctx, tx, room.ID)
+	if err != nil {
+		return false, err
+	}
+	pot := session.Pot.Add(stakes)
+	paid, err := sessionPaid(ctx, tx, session.ID)
+	if err != nil {
+		return false, err
+	}
+	prize := pot.Sub(paid)
+	if share := pot.MulBps(int64(stage.ShareBps)); !final && share.Cmp(prize) < 0 {
+		prize = share
+	}
+	var booked Money
+	err = tx.GetContext(ctx, &booked, `SELECT COALESCE(SUM(rake), 0) FROM winners WHERE session_id = $1`, session.ID)
+	if err != nil {
+		return false, err
+	}
+	rake := room.StageRake(pot, prize, booked, final)
+	netPrize := prize.Sub(rake)
+
+	n := int64(len(claims))
+	share, remainder := netPrize.Split(n)
+	rakeShare, rakeRemainder := rake.Split(n)
+
+	j := Journal{
+		Kind:   JournalWin,
+		Memo:   fmt.Sprintf("session %d stage %d", session.ID, stage.Stage),
+		Source: Source{RoomID: room.ID, SessionID: session.ID},
+		Postings: []Posting{
+			{Account: RoomEscrowAccount(room.ID), Amount: prize.Neg()},
+			{Account: HouseRevenueAccount(), Amount: rake},
+		},
+	}
+	for i, claim := range claims {
+		winnings, winnerRake := share, rakeShare
+		if int64(i) < remainder.Minor {
+			winnings = winnings.Add(NewMoney(1, winnings.Cur()))
+		}
+		if int64(i) < rakeRemainder.Minor {
+			winnerRake = winnerRake.Add(NewMoney(1, winnerRake.Cur()))
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO winners (session_id, user_id, bingo_card_id, winnings, rake, won_at, stage)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, session.ID, claim.UserID, claim.BingoCardID, winnings, winnerRake, claim.ClaimedAt, stage.Stage)
+		if err != nil {
+			return false, err
+		}
+		postings, err := payoutPostings(ctx, tx, session, claim.UserID, winnings)
+		if err != nil {
+			return false, err
+		}
+		j.Postings = append(j.Postings, postings...)
+	}
+
+	// Pay the winners and the house out of the room escrow
+	if prize.IsPositive() {
+		if _, err := postJournal(ctx, tx, j); err != nil {
+			return false, err
+		}
+	}
+	log.Printf("[settleClaims] Session %d stage %d settled: %s split among %d winner(s), rake %s",
+		session.ID, stage.Stage, netPrize, n, rake)
+
+	if !final {
+		// The next stage goes live and drawing resumes
+		next, err := json.Marshal(stages[session.CurrentStage].Pattern)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE game_sessions
+			SET pot = $2, current_stage = current_stage + 1, win_pattern = $3, claim_deadline = NULL,
+				next_draw_at = NOW() + $4 * INTERVAL '1 second'
+			WHERE id = $1
+		`, session.ID, pot, next, room.DrawIntervalSeconds)
+		return false, err
+	}
+
+	// Winners of a jackpot room's game also win the jackpot
+	if room.NoWinnerPolicy == NoWinnerJackpot {
+		if err := awardJackpot(ctx, tx, session, stage.Stage, claims); err != nil {
+			return false, err
+		}
+	}
+
+	// End session; the outcome amount is what all its winners won
+	_, err = tx.ExecContext(ctx, `
+		UPDATE game_sessions
+		SET status = 'completed', session_end_time = NOW(), pot = $2, outcome = 'won',
+			outcome_amount = (SELECT SUM(winnings) FROM winners WHERE session_id = $1)
+		WHERE id = $1
+	`, session.ID, pot)
+	return true, err
+}
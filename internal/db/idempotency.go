@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyTakeover is how long a reservation may stay incomplete before
+// another request with the key takes it over. A request still running holds
+// its reservation, so this is far longer than any request can take, provider
+// calls included; only a reservation whose request died is taken over.
+const IdempotencyTakeover = time.Hour
+
+type IdempotencyStore struct {
+	DB *sqlx.DB
+}
+
+func NewIdempotencyStore(db *sqlx.DB) *IdempotencyStore {
+	return &IdempotencyStore{DB: db}
+}
+
+// Reserve an idempotency key for a request. Returns the stored record and
+// whether this call created it; when false the key was used before and the
+// record holds the original request hash and, once completed, its response.
+// A reservation left incomplete for longer than IdempotencyTakeover (e.g. by
+// a crash) is taken over.
+func (s *IdempotencyStore) Begin(ctx context.Context, userID int64, key, method, path, requestHash string) (*IdempotencyKey, bool, error) {
+	var rec IdempotencyKey
+	err := s.DB.GetContext(ctx, &rec, `
+		INSERT INTO idempotency_keys (user_id, key, method, path, request_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, key) DO UPDATE
+		SET method = EXCLUDED.method, path = EXCLUDED.path,
+			request_hash = EXCLUDED.request_hash, created_at = NOW()
+		WHERE idempotency_keys.completed_at IS NULL
+			AND idempotency_keys.created_at < NOW() - $6 * INTERVAL '1 second'
+		RETURNING *
+	`, userID, key, method, path, requestHash, IdempotencyTakeover.Seconds())
+	if err == nil {
+		return &rec, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+	err = s.DB.GetContext(ctx, &rec, `
+		SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2
+	`, userID, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return &rec, false, nil
+}
+
+// Store the response of a reserved key so replays return it
+func (s *IdempotencyStore) Complete(ctx context.Context, id int64, statusCode int, contentType string, body []byte) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3, completed_at = NOW()
+		WHERE id = $4
+	`, statusCode, contentType, body, id)
+	return err
+}
+
+// Release a reservation whose request failed, so the client may retry with the same key
+func (s *IdempotencyStore) Release(ctx context.Context, id int64) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE id = $1 AND completed_at IS NULL`, id)
+	return err
+}
@@ -0,0 +1,348 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Ledger account kinds
+const (
+	AccountUserWallet      = "user_wallet"
+	AccountRoomEscrow      = "room_escrow"
+	AccountHouseRevenue    = "house_revenue"
+	AccountGatewayClearing = "gateway_clearing"
+	AccountTierRollover    = "tier_rollover"
+	AccountJackpot         = "jackpot"
+	AccountWithdrawalHold  = "withdrawal_hold"
+	AccountUserBonus       = "user_bonus"
+	AccountPromotions      = "promotions"
+)
+
+// Journal kinds. A journal that moves money in or out of a user wallet is
+// also listed in that user's transactions under the same type.
+const (
+	JournalDeposit  = "deposit"
+	JournalWithdraw = "withdraw"
+	JournalBet      = "bet"
+	JournalWin      = "win"
+	JournalRefund   = "refund"
+	JournalRollover = "rollover"
+	JournalJackpot  = "jackpot"
+	// A withdrawal request holds the amount, then either pays it out or returns it
+	JournalWithdrawPaid   = "withdraw_paid"
+	JournalWithdrawReturn = "withdraw_return"
+	// A promo credits a bonus, released to cash once it has been wagered
+	JournalBonus        = "bonus"
+	JournalBonusRelease = "bonus_release"
+	// Rewards paid from the promotions account when a referred user qualifies
+	JournalReferral = "referral"
+)
+
+// Balances a transaction can move: a user's cash wallet or bonus account
+const (
+	BalanceCash  = "cash"
+	BalanceBonus = "bonus"
+)
+
+// Directions of a transaction. Stakes that move on from a room escrow after
+// being bet are listed without changing a balance.
+const (
+	DirectionCredit = "credit"
+	DirectionDebit  = "debit"
+	DirectionNone   = "none"
+)
+
+var (
+	// ErrInsufficientBalance is returned when a posting would take a user wallet below zero
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrUnbalancedJournal is returned for a journal whose postings do not sum to zero
+	ErrUnbalancedJournal = errors.New("ledger journal does not balance")
+	// ErrWalletFrozen is returned when a posting would take money out of a frozen wallet
+	ErrWalletFrozen = errors.New("wallet is frozen")
+)
+
+// AccountRef identifies a ledger account by kind and owner
+type AccountRef struct {
+	Kind   string
+	UserID int64
+	RoomID int64
+	TierID int64
+}
+
+func UserWalletAccount(userID int64) AccountRef {
+	return AccountRef{Kind: AccountUserWallet, UserID: userID}
+}
+
+func RoomEscrowAccount(roomID int64) AccountRef {
+	return AccountRef{Kind: AccountRoomEscrow, RoomID: roomID}
+}
+
+func HouseRevenueAccount() AccountRef {
+	return AccountRef{Kind: AccountHouseRevenue}
+}
+
+func GatewayClearingAccount() AccountRef {
+	return AccountRef{Kind: AccountGatewayClearing}
+}
+
+// TierRolloverAccount holds pots rolled over to the next round of a room tier
+func TierRolloverAccount(tierID int64) AccountRef {
+	return AccountRef{Kind: AccountTierRollover, TierID: tierID}
+}
+
+func JackpotAccount() AccountRef {
+	return AccountRef{Kind: AccountJackpot}
+}
+
+// WithdrawalHoldAccount holds a user's funds while withdrawals are in review
+func WithdrawalHoldAccount(userID int64) AccountRef {
+	return AccountRef{Kind: AccountWithdrawalHold, UserID: userID}
+}
+
+// UserBonusAccount holds a user's bonus funds, kept apart from the cash wallet
+func UserBonusAccount(userID int64) AccountRef {
+	return AccountRef{Kind: AccountUserBonus, UserID: userID}
+}
+
+// PromotionsAccount funds the bonuses credited by promo codes and referral rewards
+func PromotionsAccount() AccountRef {
+	return AccountRef{Kind: AccountPromotions}
+}
+
+// Posting adds Amount to Account; a negative amount takes money out of it
+type Posting struct {
+	Account AccountRef
+	Amount  Money
+}
+
+// Source is what caused a journal: the room and session it was staked or
+// won in, or the deposit or withdrawal request it moved money for. It is
+// copied to the transactions the journal lists.
+type Source struct {
+	RoomID       int64
+	SessionID    int64
+	DepositID    int64
+	WithdrawalID int64
+}
+
+// Journal is one balanced money movement
+type Journal struct {
+	Kind     string
+	Memo     string
+	Source   Source
+	Postings []Posting
+}
+
+// WithSource returns the journal with its source set
+func (j Journal) WithSource(src Source) Journal {
+	j.Source = src
+	return j
+}
+
+// Transfer is a journal moving amount from one account to another
+func Transfer(kind, memo string, from, to AccountRef, amount Money) Journal {
+	return Journal{
+		Kind: kind,
+		Memo: memo,
+		Postings: []Posting{
+			{Account: from, Amount: amount.Neg()},
+			{Account: to, Amount: amount},
+		},
+	}
+}
+
+type LedgerStore struct {
+	DB *sqlx.DB
+}
+
+func NewLedgerStore(db *sqlx.DB) *LedgerStore {
+	return &LedgerStore{DB: db}
+}
+
+// Post writes a journal in its own transaction
+func (s *LedgerStore) Post(ctx context.Context, j Journal) (int64, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	journalID, err := postJournal(ctx, tx, j)
+	if err != nil {
+		return 0, err
+	}
+	return journalID, tx.Commit()
+}
+
+// Get the ledger balance of an account
+func (s *LedgerStore) Balance(ctx context.Context, ref AccountRef) (Money, error) {
+	return ledgerBalance(ctx, s.DB, ref)
+}
+
+func ledgerBalance(ctx context.Context, q sqlx.QueryerContext, ref AccountRef) (Money, error) {
+	var balance Money
+	err := sqlx.GetContext(ctx, q, &balance, `
+		SELECT COALESCE(SUM(e.amount), 0) FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.kind = $1 AND COALESCE(a.user_id, 0) = $2 AND COALESCE(a.room_id, 0) = $3
+			AND COALESCE(a.tier_id, 0) = $4
+	`, ref.Kind, ref.UserID, ref.RoomID, ref.TierID)
+	return balance, err
+}
+
+// ReconcileWallet compares the cached wallet balance of a user with the
+// balance derived from the ledger
+func (s *LedgerStore) ReconcileWallet(ctx context.Context, userID int64) (cached, ledger Money, err error) {
+	err = s.DB.GetContext(ctx, &cached, `SELECT balance FROM wallets WHERE user_id = $1`, userID)
+	if err != nil {
+		return Money{}, Money{}, err
+	}
+	ledger, err = s.Balance(ctx, UserWalletAccount(userID))
+	return cached, ledger, err
+}
+
+// postJournal writes the entries of a balanced journal inside tx. Every
+// change to wallets.balance and wallets.bonus_balance goes through here: the
+// cached balance of each user wallet or bonus account touched is updated
+// together with its entries, and the movement is listed in that user's
+// transactions. The caller commits.
+func postJournal(ctx context.Context, tx *sqlx.Tx, j Journal) (int64, error) {
+	if len(j.Postings) == 0 {
+		return 0, ErrUnbalancedJournal
+	}
+	cur := j.Postings[0].Amount.Cur()
+	sum := NewMoney(0, cur)
+	for _, p := range j.Postings {
+		if p.Amount.Cur() != cur {
+			return 0, fmt.Errorf("ledger journal mixes %s and %s", cur, p.Amount.Cur())
+		}
+		sum = sum.Add(p.Amount)
+	}
+	if !sum.IsZero() {
+		return 0, ErrUnbalancedJournal
+	}
+
+	// Apply postings in a fixed account order so concurrent journals lock
+	// wallet rows in the same order
+	postings := append([]Posting(nil), j.Postings...)
+	sort.SliceStable(postings, func(a, b int) bool {
+		pa, pb := postings[a].Account, postings[b].Account
+		if pa.Kind != pb.Kind {
+			return pa.Kind < pb.Kind
+		}
+		if pa.UserID != pb.UserID {
+			return pa.UserID < pb.UserID
+		}
+		if pa.RoomID != pb.RoomID {
+			return pa.RoomID < pb.RoomID
+		}
+		return pa.TierID < pb.TierID
+	})
+
+	var memo *string
+	if j.Memo != "" {
+		memo = &j.Memo
+	}
+	var journalID int64
+	err := tx.GetContext(ctx, &journalID, `
+		INSERT INTO ledger_journals (kind, memo) VALUES ($1, $2) RETURNING id
+	`, j.Kind, memo)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range postings {
+		if p.Amount.IsZero() {
+			continue
+		}
+		accountID, err := ledgerAccountID(ctx, tx, p.Account, cur)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO ledger_entries (journal_id, account_id, amount) VALUES ($1, $2, $3)
+		`, journalID, accountID, p.Amount)
+		if err != nil {
+			return 0, err
+		}
+		var column, balance string
+		switch p.Account.Kind {
+		case AccountUserWallet:
+			column, balance = "balance", BalanceCash
+		case AccountUserBonus:
+			column, balance = "bonus_balance", BalanceBonus
+		default:
+			continue
+		}
+
+		// A frozen wallet may still be credited
+		var frozen bool
+		err = tx.GetContext(ctx, &frozen, fmt.Sprintf(`
+			UPDATE wallets SET %[1]s = %[1]s + $1, updated_at = NOW()
+			WHERE user_id = $2 AND %[1]s + $1 >= 0
+			RETURNING frozen_at IS NOT NULL
+		`, column), p.Amount, p.Account.UserID)
+		if err == sql.ErrNoRows {
+			return 0, ErrInsufficientBalance
+		}
+		if err != nil {
+			return 0, err
+		}
+		// Releasing a bonus only moves money between the user's own balances
+		if frozen && p.Amount.IsNegative() && j.Kind != JournalBonusRelease {
+			return 0, ErrWalletFrozen
+		}
+
+		amount, direction := p.Amount, DirectionCredit
+		if amount.IsNegative() {
+			amount, direction = amount.Neg(), DirectionDebit
+		}
+		if err := recordTransaction(ctx, tx, p.Account.UserID, j.Kind, balance, direction, amount, journalID, j.Source); err != nil {
+			return 0, err
+		}
+	}
+	return journalID, nil
+}
+
+// recordTransaction lists a movement of one of a user's balances in their transactions
+func recordTransaction(ctx context.Context, tx *sqlx.Tx, userID int64, kind, balance, direction string, amount Money, journalID int64, src Source) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO transactions (user_id, type, balance, direction, amount, journal_id, room_id, session_id, deposit_id, withdrawal_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NULLIF($8, 0), NULLIF($9, 0), NULLIF($10, 0), NOW())
+	`, userID, kind, balance, direction, amount, journalID, src.RoomID, src.SessionID, src.DepositID, src.WithdrawalID)
+	return err
+}
+
+// ledgerAccountID returns the ID of an account, creating it on first use
+func ledgerAccountID(ctx context.Context, tx *sqlx.Tx, ref AccountRef, cur Currency) (int64, error) {
+	var userID, roomID, tierID *int64
+	if ref.UserID != 0 {
+		userID = &ref.UserID
+	}
+	if ref.RoomID != 0 {
+		roomID = &ref.RoomID
+	}
+	if ref.TierID != 0 {
+		tierID = &ref.TierID
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_accounts (kind, user_id, room_id, tier_id, currency)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+	`, ref.Kind, userID, roomID, tierID, string(cur))
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = tx.GetContext(ctx, &id, `
+		SELECT id FROM ledger_accounts
+		WHERE kind = $1 AND COALESCE(user_id, 0) = $2 AND COALESCE(room_id, 0) = $3
+			AND COALESCE(tier_id, 0) = $4 AND currency = $5
+	`, ref.Kind, ref.UserID, ref.RoomID, ref.TierID, string(cur))
+	return id, err
+}
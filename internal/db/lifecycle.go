@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Room statuses
+const (
+	RoomWaiting   = "waiting"
+	RoomActive    = "active"
+	RoomCompleted = "completed"
+)
+
+// ErrRoomNotRecyclable is returned for a room that is not completed, was
+// recycled already or is being recycled by another worker
+var ErrRoomNotRecyclable = errors.New("room is not ready to be recycled")
+
+// RoomStateCounts counts the rooms in each stage of their lifecycle
+type RoomStateCounts struct {
+	Waiting      int `db:"waiting"       json:"waiting"`
+	CountingDown int `db:"counting_down" json:"counting_down"`
+	Active       int `db:"active"        json:"active"`
+	Completed    int `db:"completed"     json:"completed"`
+}
+
+// DueRooms lists the waiting rooms whose countdown has ended without a session
+func (s *RoomStore) DueRooms(ctx context.Context) ([]BingoRoom, error) {
+	var rooms []BingoRoom
+	err := s.DB.SelectContext(ctx, &rooms, `
+		SELECT * FROM bingo_rooms r
+		WHERE r.status = 'waiting'
+			AND r.game_start_time IS NOT NULL AND r.game_start_time <= NOW()
+			AND NOT EXISTS (SELECT 1 FROM game_sessions s WHERE s.room_id = r.id AND s.status = 'active')
+		ORDER BY r.game_start_time
+	`)
+	return rooms, err
+}
+
+// CompleteRooms marks the active rooms whose session has ended as completed
+// and returns their IDs
+func (s *RoomStore) CompleteRooms(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	err := s.DB.SelectContext(ctx, &ids, `
+		UPDATE bingo_rooms r SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+		WHERE r.status = 'active'
+			AND EXISTS (SELECT 1 FROM game_sessions s WHERE s.room_id = r.id)
+			AND NOT EXISTS (SELECT 1 FROM game_sessions s WHERE s.room_id = r.id AND s.status = 'active')
+		RETURNING r.id
+	`)
+	return ids, err
+}
+
+// RecyclableRooms lists the rooms completed at least after ago and not yet recycled
+func (s *RoomStore) RecyclableRooms(ctx context.Context, after time.Duration) ([]int64, error) {
+	var ids []int64
+	err := s.DB.SelectContext(ctx, &ids, `
+		SELECT id FROM bingo_rooms
+		WHERE status = 'completed' AND recycled_at IS NULL
+			AND completed_at <= NOW() - $1 * INTERVAL '1 second'
+		ORDER BY completed_at
+	`, int64(after/time.Second))
+	return ids, err
+}
+
+// RecycleRoom retires a completed room and returns the waiting room that
+// takes its place: one for the same bet amount with space, created if there
+// is none. Stakes still held in the retired room are refunded. Returns
+// ErrRoomNotRecyclable if the room is not due or is being recycled already.
+func (s *RoomStore) RecycleRoom(ctx context.Context, roomID int64, after time.Duration) (*BingoRoom, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var room BingoRoom
+	err = tx.GetContext(ctx, &room, `
+		SELECT * FROM bingo_rooms
+		WHERE id = $1 AND status = 'completed' AND recycled_at IS NULL
+			AND completed_at <= NOW() - $2 * INTERVAL '1 second'
+		FOR UPDATE SKIP LOCKED
+	`, roomID, int64(after/time.Second))
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomNotRecyclable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bets, _, err := closeStakes(ctx, tx, room.ID, BetRefunded)
+	if err != nil {
+		return nil, err
+	}
+	if err := refundBets(ctx, tx, room.ID, bets); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE bingo_rooms SET recycled_at = NOW(), updated_at = NOW() WHERE id = $1
+	`, room.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	variant, err := room.CardVariant()
+	if err != nil {
+		return nil, err
+	}
+	return s.FindOrCreateRoom(ctx, room.BetAmount, variant)
+}
+
+// CountRoomStates counts the rooms in each stage of their lifecycle
+func (s *RoomStore) CountRoomStates(ctx context.Context) (*RoomStateCounts, error) {
+	var counts RoomStateCounts
+	err := s.DB.GetContext(ctx, &counts, `
+		SELECT
+			COUNT(*) FILTER (WHERE status = 'waiting' AND countdown_start IS NULL) AS waiting,
+			COUNT(*) FILTER (WHERE status = 'waiting' AND countdown_start IS NOT NULL) AS counting_down,
+			COUNT(*) FILTER (WHERE status = 'active') AS active,
+			COUNT(*) FILTER (WHERE status = 'completed' AND recycled_at IS NULL) AS completed
+		FROM bingo_rooms
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
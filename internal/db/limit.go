@@ -0,0 +1,483 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Gaming limit kinds
+const (
+	LimitDeposit  = "deposit"
+	LimitLoss     = "loss"
+	LimitPlayTime = "play_time"
+)
+
+// Gaming limit periods, each a rolling window ending now
+const (
+	LimitDaily   = "daily"
+	LimitWeekly  = "weekly"
+	LimitMonthly = "monthly"
+)
+
+var limitPeriods = map[string]time.Duration{
+	LimitDaily:   24 * time.Hour,
+	LimitWeekly:  7 * 24 * time.Hour,
+	LimitMonthly: 30 * 24 * time.Hour,
+}
+
+// Global config for responsible gaming. Raising or removing a limit and
+// ending a self-exclusion early only take effect after CoolingOff.
+type LimitConfig struct {
+	CoolingOff time.Duration
+}
+
+var limitConfig = LimitConfig{CoolingOff: 24 * time.Hour} // default
+
+// Set limit config from env
+func SetLimitConfigFromEnv() {
+	if val, ok := lookupEnvInt("LIMIT_COOLING_OFF_HOURS"); ok && val >= 0 {
+		limitConfig.CoolingOff = time.Duration(val) * time.Hour
+	}
+}
+
+// GetLimitConfig returns the responsible gaming config in effect
+func GetLimitConfig() LimitConfig {
+	return limitConfig
+}
+
+var (
+	// ErrSelfExcluded is returned when a self-excluded user deposits or plays
+	ErrSelfExcluded = errors.New("you are self-excluded")
+	// ErrNotSelfExcluded is returned when ending a self-exclusion that is not in effect
+	ErrNotSelfExcluded = errors.New("no self-exclusion in effect")
+	// ErrDepositLimit is returned for a deposit that would exceed a deposit limit
+	ErrDepositLimit = errors.New("deposit limit reached")
+	// ErrLossLimit is returned for a stake that could take losses over a loss limit
+	ErrLossLimit = errors.New("loss limit reached")
+	// ErrPlayTimeLimit is returned when joining a game after a play time limit is used up
+	ErrPlayTimeLimit = errors.New("play time limit reached")
+)
+
+// Validate checks a limit a user sets: an amount for deposit and loss
+// limits, minutes for play time limits
+func (l *GamingLimit) Validate() error {
+	if _, ok := limitPeriods[l.Period]; !ok {
+		return fmt.Errorf("unsupported limit period %q", l.Period)
+	}
+	switch l.Kind {
+	case LimitDeposit, LimitLoss:
+		if l.Amount == nil || l.Minutes != nil {
+			return fmt.Errorf("a %s limit needs an amount", l.Kind)
+		}
+		if l.Amount.IsNegative() {
+			return errors.New("amount must not be negative")
+		}
+	case LimitPlayTime:
+		if l.Minutes == nil || l.Amount != nil {
+			return errors.New("a play time limit needs minutes")
+		}
+		if *l.Minutes < 0 {
+			return errors.New("minutes must not be negative")
+		}
+	default:
+		return fmt.Errorf("unsupported limit kind %q", l.Kind)
+	}
+	return nil
+}
+
+// looser reports whether limit l allows more than o
+func (l *GamingLimit) looser(o *GamingLimit) bool {
+	if l.Kind == LimitPlayTime {
+		return *l.Minutes > *o.Minutes
+	}
+	return l.Amount.Cmp(*o.Amount) > 0
+}
+
+// LimitUsage is a limit with how much of it the current period has used
+type LimitUsage struct {
+	GamingLimit
+	UsedAmount  *Money `json:"used_amount,omitempty"`
+	UsedMinutes *int   `json:"used_minutes,omitempty"`
+}
+
+type LimitStore struct {
+	DB *sqlx.DB
+}
+
+func NewLimitStore(db *sqlx.DB) *LimitStore {
+	return &LimitStore{DB: db}
+}
+
+// ListLimits returns the user's limits in effect, with any pending change,
+// and what each period has used so far
+func (s *LimitStore) ListLimits(ctx context.Context, userID int64) ([]LimitUsage, error) {
+	if err := applyDueLimits(ctx, s.DB, userID); err != nil {
+		return nil, err
+	}
+	var limits []GamingLimit
+	err := s.DB.SelectContext(ctx, &limits, `
+		SELECT * FROM gaming_limits WHERE user_id = $1 ORDER BY kind, period
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]LimitUsage, 0, len(limits))
+	for _, l := range limits {
+		u := LimitUsage{GamingLimit: l}
+		switch l.Kind {
+		case LimitDeposit:
+			used, err := depositedSince(ctx, s.DB, userID, limitPeriods[l.Period])
+			if err != nil {
+				return nil, err
+			}
+			u.UsedAmount = &used
+		case LimitLoss:
+			used, err := lostSince(ctx, s.DB, userID, limitPeriods[l.Period])
+			if err != nil {
+				return nil, err
+			}
+			u.UsedAmount = &used
+		case LimitPlayTime:
+			used, err := playedSince(ctx, s.DB, userID, limitPeriods[l.Period])
+			if err != nil {
+				return nil, err
+			}
+			u.UsedMinutes = &used
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// SetLimit sets one of the user's limits. A new or lower limit applies at
+// once; a higher one is kept pending until the cooling-off period is over,
+// and the current limit stays in effect until then.
+func (s *LimitStore) SetLimit(ctx context.Context, limit GamingLimit) (*GamingLimit, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := applyDueLimits(ctx, tx, limit.UserID); err != nil {
+		return nil, err
+	}
+	var current GamingLimit
+	err = tx.GetContext(ctx, &current, `
+		SELECT * FROM gaming_limits WHERE user_id = $1 AND kind = $2 AND period = $3 FOR UPDATE
+	`, limit.UserID, limit.Kind, limit.Period)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var saved GamingLimit
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.GetContext(ctx, &saved, `
+			INSERT INTO gaming_limits (user_id, kind, period, amount, minutes)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, kind, period) DO UPDATE
+			SET amount = LEAST(gaming_limits.amount, EXCLUDED.amount),
+				minutes = LEAST(gaming_limits.minutes, EXCLUDED.minutes),
+				updated_at = NOW()
+			RETURNING *
+		`, limit.UserID, limit.Kind, limit.Period, limit.Amount, limit.Minutes)
+	case limit.looser(&current):
+		err = tx.GetContext(ctx, &saved, `
+			UPDATE gaming_limits
+			SET pending_amount = $2, pending_minutes = $3,
+				pending_at = NOW() + $4 * INTERVAL '1 second', updated_at = NOW()
+			WHERE id = $1
+			RETURNING *
+		`, current.ID, limit.Amount, limit.Minutes, int64(limitConfig.CoolingOff/time.Second))
+	default:
+		err = tx.GetContext(ctx, &saved, `
+			UPDATE gaming_limits
+			SET amount = $2, minutes = $3,
+				pending_amount = NULL, pending_minutes = NULL, pending_at = NULL, updated_at = NOW()
+			WHERE id = $1
+			RETURNING *
+		`, current.ID, limit.Amount, limit.Minutes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// RemoveLimit schedules the removal of one of the user's limits for the end
+// of the cooling-off period. Returns sql.ErrNoRows if the user has no such limit.
+func (s *LimitStore) RemoveLimit(ctx context.Context, userID int64, kind, period string) (*GamingLimit, error) {
+	if err := applyDueLimits(ctx, s.DB, userID); err != nil {
+		return nil, err
+	}
+	var limit GamingLimit
+	err := s.DB.GetContext(ctx, &limit, `
+		UPDATE gaming_limits
+		SET pending_amount = NULL, pending_minutes = NULL,
+			pending_at = NOW() + $4 * INTERVAL '1 second', updated_at = NOW()
+		WHERE user_id = $1 AND kind = $2 AND period = $3
+		RETURNING *
+	`, userID, kind, period, int64(limitConfig.CoolingOff/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// GetSelfExclusion returns the user's self-exclusion in effect, or
+// sql.ErrNoRows if there is none
+func (s *LimitStore) GetSelfExclusion(ctx context.Context, userID int64) (*SelfExclusion, error) {
+	var ex SelfExclusion
+	err := s.DB.GetContext(ctx, &ex, `
+		SELECT * FROM self_exclusions WHERE user_id = $1 AND ends_at > NOW()
+		ORDER BY ends_at DESC LIMIT 1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &ex, nil
+}
+
+// SelfExclude excludes the user from depositing and playing for d. An
+// exclusion already in effect is only ever extended, which also cancels a
+// request to end it early.
+func (s *LimitStore) SelfExclude(ctx context.Context, userID int64, d time.Duration) (*SelfExclusion, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockLimits(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	seconds := int64(d / time.Second)
+	var ex SelfExclusion
+	err = tx.GetContext(ctx, &ex, `
+		UPDATE self_exclusions
+		SET ends_at = GREATEST(ends_at, NOW() + $2 * INTERVAL '1 second'),
+			end_requested_at = CASE WHEN ends_at < NOW() + $2 * INTERVAL '1 second' THEN NULL ELSE end_requested_at END
+		WHERE user_id = $1 AND ends_at > NOW()
+		RETURNING *
+	`, userID, seconds)
+	if err == sql.ErrNoRows {
+		err = tx.GetContext(ctx, &ex, `
+			INSERT INTO self_exclusions (user_id, ends_at)
+			VALUES ($1, NOW() + $2 * INTERVAL '1 second')
+			RETURNING *
+		`, userID, seconds)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &ex, nil
+}
+
+// EndSelfExclusion asks to end the user's self-exclusion early. It then ends
+// after the cooling-off period, or at its own end if that comes first.
+func (s *LimitStore) EndSelfExclusion(ctx context.Context, userID int64) (*SelfExclusion, error) {
+	var ex SelfExclusion
+	err := s.DB.GetContext(ctx, &ex, `
+		UPDATE self_exclusions
+		SET ends_at = LEAST(ends_at, NOW() + $2 * INTERVAL '1 second'),
+			end_requested_at = COALESCE(end_requested_at, NOW())
+		WHERE user_id = $1 AND ends_at > NOW()
+		RETURNING *
+	`, userID, int64(limitConfig.CoolingOff/time.Second))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotSelfExcluded
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ex, nil
+}
+
+// CheckPlay returns why the user may not stake amount now, if anything
+// stops them. Staking checks again when the stake is taken.
+func (s *LimitStore) CheckPlay(ctx context.Context, userID int64, amount Money) error {
+	if err := applyDueLimits(ctx, s.DB, userID); err != nil {
+		return err
+	}
+	return checkPlayLimits(ctx, s.DB, userID, amount)
+}
+
+// lockLimits serializes the deposits, stakes and exclusions of a user, so
+// concurrent requests cannot each stay within a limit they exceed together
+func lockLimits(ctx context.Context, tx *sqlx.Tx, userID int64) error {
+	_, err := tx.ExecContext(ctx, `SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE`, userID)
+	return err
+}
+
+// applyDueLimits puts into effect the user's pending limit changes whose
+// cooling-off period is over
+func applyDueLimits(ctx context.Context, q sqlx.ExtContext, userID int64) error {
+	_, err := q.ExecContext(ctx, `
+		DELETE FROM gaming_limits
+		WHERE user_id = $1 AND pending_at <= NOW() AND pending_amount IS NULL AND pending_minutes IS NULL
+	`, userID)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `
+		UPDATE gaming_limits
+		SET amount = pending_amount, minutes = pending_minutes,
+			pending_amount = NULL, pending_minutes = NULL, pending_at = NULL, updated_at = NOW()
+		WHERE user_id = $1 AND pending_at <= NOW()
+	`, userID)
+	return err
+}
+
+// checkSelfExclusion returns ErrSelfExcluded while the user is self-excluded
+func checkSelfExclusion(ctx context.Context, q sqlx.QueryerContext, userID int64) error {
+	var excluded bool
+	err := sqlx.GetContext(ctx, q, &excluded, `
+		SELECT EXISTS (SELECT 1 FROM self_exclusions WHERE user_id = $1 AND ends_at > NOW())
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if excluded {
+		return ErrSelfExcluded
+	}
+	return nil
+}
+
+// userLimits returns the user's limits of a kind in effect
+func userLimits(ctx context.Context, q sqlx.QueryerContext, userID int64, kind string) ([]GamingLimit, error) {
+	var limits []GamingLimit
+	err := sqlx.SelectContext(ctx, q, &limits, `
+		SELECT * FROM gaming_limits WHERE user_id = $1 AND kind = $2
+	`, userID, kind)
+	return limits, err
+}
+
+// checkDepositLimits returns an error wrapping ErrDepositLimit if a deposit
+// of amount would take the user over a deposit limit. Deposits still
+// pending count, so a user cannot open several checkouts past their limit.
+func checkDepositLimits(ctx context.Context, tx *sqlx.Tx, userID int64, amount Money) error {
+	if err := applyDueLimits(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := checkSelfExclusion(ctx, tx, userID); err != nil {
+		return err
+	}
+	limits, err := userLimits(ctx, tx, userID, LimitDeposit)
+	if err != nil {
+		return err
+	}
+	for _, l := range limits {
+		used, err := depositedSince(ctx, tx, userID, limitPeriods[l.Period])
+		if err != nil {
+			return err
+		}
+		if used.Add(amount).Cmp(*l.Amount) > 0 {
+			return fmt.Errorf("%s %w", l.Period, ErrDepositLimit)
+		}
+	}
+	return nil
+}
+
+// checkPlayLimits returns why the user may not stake amount: a
+// self-exclusion, a loss limit the stake could exceed if lost, or a play
+// time limit already used up
+func checkPlayLimits(ctx context.Context, q sqlx.QueryerContext, userID int64, amount Money) error {
+	if err := checkSelfExclusion(ctx, q, userID); err != nil {
+		return err
+	}
+	limits, err := userLimits(ctx, q, userID, LimitLoss)
+	if err != nil {
+		return err
+	}
+	for _, l := range limits {
+		lost, err := lostSince(ctx, q, userID, limitPeriods[l.Period])
+		if err != nil {
+			return err
+		}
+		if lost.Add(amount).Cmp(*l.Amount) > 0 {
+			return fmt.Errorf("%s %w", l.Period, ErrLossLimit)
+		}
+	}
+	limits, err = userLimits(ctx, q, userID, LimitPlayTime)
+	if err != nil {
+		return err
+	}
+	for _, l := range limits {
+		played, err := playedSince(ctx, q, userID, limitPeriods[l.Period])
+		if err != nil {
+			return err
+		}
+		if played >= *l.Minutes {
+			return fmt.Errorf("%s %w", l.Period, ErrPlayTimeLimit)
+		}
+	}
+	return nil
+}
+
+// checkStakeLimits is checkPlayLimits for a stake about to be taken
+func checkStakeLimits(ctx context.Context, tx *sqlx.Tx, userID int64, amount Money) error {
+	if err := lockLimits(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := applyDueLimits(ctx, tx, userID); err != nil {
+		return err
+	}
+	return checkPlayLimits(ctx, tx, userID, amount)
+}
+
+// depositedSince sums the user's pending and completed deposits over the last d
+func depositedSince(ctx context.Context, q sqlx.QueryerContext, userID int64, d time.Duration) (Money, error) {
+	var total Money
+	err := sqlx.GetContext(ctx, q, &total, `
+		SELECT COALESCE(SUM(amount), 0) FROM payment_deposits
+		WHERE user_id = $1 AND status IN ('pending', 'completed')
+			AND created_at > NOW() - $2 * INTERVAL '1 second'
+	`, userID, int64(d/time.Second))
+	return total, err
+}
+
+// lostSince returns the user's stakes over the last d less their winnings
+// over the same time. Stakes still held count as lost.
+func lostSince(ctx context.Context, q sqlx.QueryerContext, userID int64, d time.Duration) (Money, error) {
+	var lost Money
+	err := sqlx.GetContext(ctx, q, &lost, `
+		SELECT
+			COALESCE((
+				SELECT SUM(bet_amount) FROM user_bets
+				WHERE user_id = $1 AND status <> 'refunded' AND created_at > NOW() - $2 * INTERVAL '1 second'
+			), 0) - COALESCE((
+				SELECT SUM(winnings) FROM winners
+				WHERE user_id = $1 AND won_at > NOW() - $2 * INTERVAL '1 second'
+			), 0)
+	`, userID, int64(d/time.Second))
+	return lost, err
+}
+
+// playedSince returns the minutes the user spent over the last d in games
+// they had a stake in, from the start of each game to its settlement
+func playedSince(ctx context.Context, q sqlx.QueryerContext, userID int64, d time.Duration) (int, error) {
+	var seconds float64
+	err := sqlx.GetContext(ctx, q, &seconds, `
+		SELECT COALESCE(SUM(EXTRACT(EPOCH FROM
+			COALESCE(b.settled_at, NOW()) - GREATEST(s.session_start_time, NOW() - $2 * INTERVAL '1 second')
+		)), 0)
+		FROM user_bets b
+		JOIN game_sessions s ON s.room_id = b.room_id
+			AND s.session_start_time BETWEEN b.created_at AND COALESCE(b.settled_at, NOW())
+		WHERE b.user_id = $1 AND b.status <> 'refunded'
+			AND COALESCE(b.settled_at, NOW()) > NOW() - $2 * INTERVAL '1 second'
+	`, userID, int64(d/time.Second))
+	return int(seconds / 60), err
+}
@@ -0,0 +1,2 @@
+-- Bumping token_version revokes every API session token issued to the user
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Access role of a user: player (default), operator or admin
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'player'
+    CHECK (role IN ('player', 'operator', 'admin'));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_deposits_transaction_ref;
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,25 @@
+-- Stored results of POST requests sent with an Idempotency-Key header
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    key VARCHAR(255) NOT NULL,
+    method VARCHAR(8) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(128),
+    response_body BYTEA,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    UNIQUE (user_id, key)
+);
+
+-- Rename any existing duplicate deposit references so the unique index can be built
+UPDATE payment_deposits d
+SET transaction_ref = d.transaction_ref || '-dup-' || d.id
+WHERE EXISTS (
+    SELECT 1 FROM payment_deposits o
+    WHERE o.transaction_ref = d.transaction_ref AND o.id < d.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_deposits_transaction_ref ON payment_deposits(transaction_ref);
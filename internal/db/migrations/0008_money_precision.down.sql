@@ -0,0 +1,6 @@
+ALTER TABLE payment_deposits ALTER COLUMN amount TYPE NUMERIC;
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC;
+ALTER TABLE wallets ALTER COLUMN balance TYPE NUMERIC;
+ALTER TABLE user_bets ALTER COLUMN bet_amount TYPE NUMERIC;
+ALTER TABLE winners ALTER COLUMN winnings TYPE NUMERIC;
+ALTER TABLE bingo_rooms ALTER COLUMN bet_amount TYPE NUMERIC;
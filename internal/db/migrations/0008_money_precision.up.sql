@@ -0,0 +1,7 @@
+-- Store every money amount as exact ETB with two decimal places
+ALTER TABLE bingo_rooms ALTER COLUMN bet_amount TYPE NUMERIC(18,2);
+ALTER TABLE winners ALTER COLUMN winnings TYPE NUMERIC(18,2);
+ALTER TABLE user_bets ALTER COLUMN bet_amount TYPE NUMERIC(18,2);
+ALTER TABLE wallets ALTER COLUMN balance TYPE NUMERIC(18,2);
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(18,2);
+ALTER TABLE payment_deposits ALTER COLUMN amount TYPE NUMERIC(18,2);
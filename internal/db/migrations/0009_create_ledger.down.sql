@@ -0,0 +1,8 @@
+ALTER TABLE wallets ALTER COLUMN balance DROP NOT NULL;
+ALTER TABLE wallets ALTER COLUMN balance DROP DEFAULT;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS journal_id;
+
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_journals;
+DROP TABLE IF EXISTS ledger_accounts;
@@ -0,0 +1,62 @@
+-- Ledger accounts: user wallets, room escrows and system accounts
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    user_id INTEGER REFERENCES users(id),
+    room_id INTEGER REFERENCES bingo_rooms(id),
+    currency VARCHAR(3) NOT NULL DEFAULT 'ETB',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_owner
+    ON ledger_accounts(kind, COALESCE(user_id, 0), COALESCE(room_id, 0), currency);
+
+-- A journal groups the entries of one balanced money movement
+CREATE TABLE IF NOT EXISTS ledger_journals (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    memo TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Entries of a journal sum to zero; a positive amount increases the account
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id SERIAL PRIMARY KEY,
+    journal_id INTEGER NOT NULL REFERENCES ledger_journals(id),
+    account_id INTEGER NOT NULL REFERENCES ledger_accounts(id),
+    amount NUMERIC(18,2) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_journal ON ledger_entries(journal_id);
+
+ALTER TABLE transactions ADD COLUMN journal_id INTEGER REFERENCES ledger_journals(id);
+
+-- Open the ledger with the existing wallet balances, funded from gateway clearing
+INSERT INTO ledger_accounts (kind) VALUES ('gateway_clearing'), ('house_revenue')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_accounts (kind, user_id)
+SELECT DISTINCT 'user_wallet', user_id FROM wallets WHERE user_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+WITH opening AS (
+    INSERT INTO ledger_journals (kind, memo)
+    VALUES ('opening_balance', 'Wallet balances carried over into the ledger')
+    RETURNING id
+)
+INSERT INTO ledger_entries (journal_id, account_id, amount)
+SELECT opening.id, a.id, w.balance
+FROM opening, wallets w
+JOIN ledger_accounts a ON a.kind = 'user_wallet' AND a.user_id = w.user_id
+WHERE COALESCE(w.balance, 0) <> 0
+UNION ALL
+SELECT opening.id, g.id, -SUM(w.balance)
+FROM opening, wallets w, ledger_accounts g
+WHERE g.kind = 'gateway_clearing' AND COALESCE(w.balance, 0) <> 0
+GROUP BY opening.id, g.id;
+
+UPDATE wallets SET balance = 0 WHERE balance IS NULL;
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0;
+ALTER TABLE wallets ALTER COLUMN balance SET NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_bets_held;
+ALTER TABLE user_bets DROP COLUMN IF EXISTS settled_at;
+ALTER TABLE user_bets DROP COLUMN IF EXISTS status;
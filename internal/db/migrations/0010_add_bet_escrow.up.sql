@@ -0,0 +1,10 @@
+-- Stakes are held in the room escrow until they are refunded or settled
+ALTER TABLE user_bets ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'held'
+    CHECK (status IN ('held', 'refunded', 'settled'));
+ALTER TABLE user_bets ADD COLUMN settled_at TIMESTAMPTZ;
+
+-- Bets placed before the ledger were never debited, so there is nothing to pay out or refund
+UPDATE user_bets SET status = 'settled', settled_at = NOW();
+
+-- One held stake per player and room
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_bets_held ON user_bets(room_id, user_id) WHERE status = 'held';
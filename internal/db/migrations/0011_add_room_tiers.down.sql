@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_ledger_accounts_owner;
+DELETE FROM ledger_entries WHERE account_id IN (SELECT id FROM ledger_accounts WHERE tier_id IS NOT NULL);
+DELETE FROM ledger_accounts WHERE tier_id IS NOT NULL;
+ALTER TABLE ledger_accounts DROP COLUMN IF EXISTS tier_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_owner
+    ON ledger_accounts(kind, COALESCE(user_id, 0), COALESCE(room_id, 0), currency);
+
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS outcome_amount;
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS outcome;
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS carried_pot;
+
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS no_winner_policy;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS tier_id;
+
+DROP TABLE IF EXISTS room_tiers;
@@ -0,0 +1,26 @@
+-- Room tiers group rooms by bet amount and carry their settlement policy
+CREATE TABLE IF NOT EXISTS room_tiers (
+    id SERIAL PRIMARY KEY,
+    bet_amount NUMERIC(18,2) NOT NULL UNIQUE,
+    no_winner_policy VARCHAR(16) NOT NULL DEFAULT 'refund'
+        CHECK (no_winner_policy IN ('refund', 'rollover', 'jackpot')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rooms copy the policy of their tier when they are created
+ALTER TABLE bingo_rooms ADD COLUMN tier_id INTEGER REFERENCES room_tiers(id);
+ALTER TABLE bingo_rooms ADD COLUMN no_winner_policy VARCHAR(16) NOT NULL DEFAULT 'refund'
+    CHECK (no_winner_policy IN ('refund', 'rollover', 'jackpot'));
+
+-- How a session's pot was settled
+ALTER TABLE game_sessions ADD COLUMN carried_pot NUMERIC(18,2) NOT NULL DEFAULT 0;
+ALTER TABLE game_sessions ADD COLUMN outcome VARCHAR(16)
+    CHECK (outcome IN ('won', 'refunded', 'rolled_over', 'jackpot'));
+ALTER TABLE game_sessions ADD COLUMN outcome_amount NUMERIC(18,2);
+
+-- Rollover pools are kept per tier
+ALTER TABLE ledger_accounts ADD COLUMN tier_id INTEGER REFERENCES room_tiers(id);
+DROP INDEX IF EXISTS idx_ledger_accounts_owner;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_owner
+    ON ledger_accounts(kind, COALESCE(user_id, 0), COALESCE(room_id, 0), COALESCE(tier_id, 0), currency);
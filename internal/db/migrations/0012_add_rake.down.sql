@@ -0,0 +1,9 @@
+ALTER TABLE winners DROP COLUMN IF EXISTS rake;
+
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS rake_cap;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS rake_min;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS rake_bps;
+
+ALTER TABLE room_tiers DROP COLUMN IF EXISTS rake_cap;
+ALTER TABLE room_tiers DROP COLUMN IF EXISTS rake_min;
+ALTER TABLE room_tiers DROP COLUMN IF EXISTS rake_bps;
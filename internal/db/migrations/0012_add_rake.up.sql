@@ -0,0 +1,11 @@
+-- House commission taken from the pot at payout: rake_bps basis points of
+-- the pot, at least rake_min and at most rake_cap (no cap when NULL)
+ALTER TABLE room_tiers ADD COLUMN rake_bps INTEGER NOT NULL DEFAULT 0 CHECK (rake_bps BETWEEN 0 AND 10000);
+ALTER TABLE room_tiers ADD COLUMN rake_min NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (rake_min >= 0);
+ALTER TABLE room_tiers ADD COLUMN rake_cap NUMERIC(18,2) CHECK (rake_cap >= 0);
+
+ALTER TABLE bingo_rooms ADD COLUMN rake_bps INTEGER NOT NULL DEFAULT 0 CHECK (rake_bps BETWEEN 0 AND 10000);
+ALTER TABLE bingo_rooms ADD COLUMN rake_min NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (rake_min >= 0);
+ALTER TABLE bingo_rooms ADD COLUMN rake_cap NUMERIC(18,2) CHECK (rake_cap >= 0);
+
+ALTER TABLE winners ADD COLUMN rake NUMERIC(18,2) NOT NULL DEFAULT 0;
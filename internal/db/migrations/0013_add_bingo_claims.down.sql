@@ -0,0 +1,2 @@
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS claim_deadline;
+DROP TABLE IF EXISTS bingo_claims;
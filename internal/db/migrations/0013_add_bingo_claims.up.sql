@@ -0,0 +1,14 @@
+-- Valid bingo claims collected during a session's claim window
+CREATE TABLE IF NOT EXISTS bingo_claims (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES game_sessions(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    bingo_card_id INTEGER REFERENCES bingo_cards(id),
+    card_number INTEGER NOT NULL,
+    draw_count INTEGER NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (session_id, user_id)
+);
+
+-- Set by the first valid claim; the pot is split among all claims once it passes
+ALTER TABLE game_sessions ADD COLUMN claim_deadline TIMESTAMPTZ;
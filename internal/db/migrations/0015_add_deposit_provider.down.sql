@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payment_deposits_provider_ref;
+ALTER TABLE payment_deposits DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE payment_deposits DROP COLUMN IF EXISTS checkout_url;
+ALTER TABLE payment_deposits DROP COLUMN IF EXISTS provider_ref;
+ALTER TABLE payment_deposits DROP COLUMN IF EXISTS provider;
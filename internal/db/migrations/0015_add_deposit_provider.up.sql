@@ -0,0 +1,9 @@
+-- Deposits are paid through a payment provider checkout and credited only
+-- when the provider confirms them by webhook.
+ALTER TABLE payment_deposits ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+ALTER TABLE payment_deposits ADD COLUMN IF NOT EXISTS provider_ref VARCHAR(128);
+ALTER TABLE payment_deposits ADD COLUMN IF NOT EXISTS checkout_url TEXT;
+ALTER TABLE payment_deposits ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_deposits_provider_ref
+    ON payment_deposits(provider, provider_ref) WHERE provider_ref IS NOT NULL;
@@ -0,0 +1,13 @@
+UPDATE withdrawal_requests SET status = 'approved' WHERE status = 'processing';
+ALTER TABLE withdrawal_requests DROP CONSTRAINT IF EXISTS withdrawal_requests_status_check;
+ALTER TABLE withdrawal_requests ADD CONSTRAINT withdrawal_requests_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'paid', 'failed'));
+
+ALTER TABLE withdrawal_requests DROP COLUMN IF EXISTS last_error;
+ALTER TABLE withdrawal_requests DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE withdrawal_requests DROP COLUMN IF EXISTS payout_attempts;
+ALTER TABLE withdrawal_requests DROP COLUMN IF EXISTS provider_ref;
+ALTER TABLE withdrawal_requests DROP COLUMN IF EXISTS provider;
+ALTER TABLE withdrawal_requests DROP COLUMN IF EXISTS payout_method_id;
+
+DROP TABLE IF EXISTS payout_methods;
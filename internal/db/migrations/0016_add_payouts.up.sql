@@ -0,0 +1,23 @@
+-- Payout methods are the validated destinations withdrawals are sent to
+CREATE TABLE IF NOT EXISTS payout_methods (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    method_type VARCHAR(32) NOT NULL CHECK (method_type IN ('mobile_money', 'bank_account')),
+    account VARCHAR(64) NOT NULL,
+    bank_code VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, method_type, account, bank_code)
+);
+
+-- Approved withdrawals are sent through a payout provider: approved ->
+-- processing -> paid/failed, going back to approved to be retried
+ALTER TABLE withdrawal_requests ADD COLUMN IF NOT EXISTS payout_method_id INTEGER REFERENCES payout_methods(id);
+ALTER TABLE withdrawal_requests ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+ALTER TABLE withdrawal_requests ADD COLUMN IF NOT EXISTS provider_ref VARCHAR(128);
+ALTER TABLE withdrawal_requests ADD COLUMN IF NOT EXISTS payout_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE withdrawal_requests ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
+ALTER TABLE withdrawal_requests ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+ALTER TABLE withdrawal_requests DROP CONSTRAINT IF EXISTS withdrawal_requests_status_check;
+ALTER TABLE withdrawal_requests ADD CONSTRAINT withdrawal_requests_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'processing', 'paid', 'failed'));
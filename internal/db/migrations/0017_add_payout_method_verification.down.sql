@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payout_methods_unverified;
+ALTER TABLE payout_methods DROP COLUMN IF EXISTS removed_at;
+ALTER TABLE payout_methods DROP COLUMN IF EXISTS verified_by;
+ALTER TABLE payout_methods DROP COLUMN IF EXISTS verified_at;
+ALTER TABLE payout_methods DROP COLUMN IF EXISTS label;
@@ -0,0 +1,10 @@
+-- Players register payout methods ahead of time; withdrawals may only target
+-- a method an operator has verified. Removed methods are kept for the
+-- withdrawals that reference them.
+ALTER TABLE payout_methods ADD COLUMN IF NOT EXISTS label VARCHAR(64);
+ALTER TABLE payout_methods ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
+ALTER TABLE payout_methods ADD COLUMN IF NOT EXISTS verified_by INTEGER REFERENCES users(id);
+ALTER TABLE payout_methods ADD COLUMN IF NOT EXISTS removed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_payout_methods_unverified ON payout_methods(created_at)
+    WHERE verified_at IS NULL AND removed_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen_reason;
+ALTER TABLE wallets DROP COLUMN IF EXISTS frozen_at;
+DROP TABLE IF EXISTS reconciliation_discrepancies;
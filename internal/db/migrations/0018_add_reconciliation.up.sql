@@ -0,0 +1,24 @@
+-- Discrepancies found by the reconciliation job: a wallet whose cached
+-- balance differs from its ledger account, or a room escrow holding more or
+-- less than its held stakes and carried pot. One open row per wallet/room.
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('wallet', 'room')),
+    user_id INTEGER REFERENCES users(id),
+    room_id INTEGER REFERENCES bingo_rooms(id),
+    expected NUMERIC(18,2) NOT NULL,
+    actual NUMERIC(18,2) NOT NULL,
+    detected_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMPTZ,
+    resolved_by INTEGER REFERENCES users(id),
+    resolution_note TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_open
+    ON reconciliation_discrepancies(kind, COALESCE(user_id, 0), COALESCE(room_id, 0))
+    WHERE resolved_at IS NULL;
+
+-- A frozen wallet can receive money but not spend or withdraw it
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen_at TIMESTAMPTZ;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS frozen_reason TEXT;
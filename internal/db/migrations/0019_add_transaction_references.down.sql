@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_user_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS withdrawal_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS deposit_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS session_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS room_id;
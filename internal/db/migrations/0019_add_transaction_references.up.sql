@@ -0,0 +1,31 @@
+-- What caused each transaction: the room and session it was staked or won
+-- in, or the deposit or withdrawal request it moved money for
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS room_id INTEGER REFERENCES bingo_rooms(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS session_id INTEGER REFERENCES game_sessions(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deposit_id INTEGER REFERENCES payment_deposits(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS withdrawal_id INTEGER REFERENCES withdrawal_requests(id);
+
+-- Backfill from the memos of the journals written so far
+UPDATE transactions t
+SET room_id = substring(j.memo from '^(?:carried into )?room (\d+)$')::integer
+FROM ledger_journals j
+WHERE j.id = t.journal_id AND j.memo ~ '^(carried into )?room \d+$';
+
+UPDATE transactions t
+SET session_id = s.id, room_id = s.room_id
+FROM ledger_journals j, game_sessions s
+WHERE j.id = t.journal_id AND j.memo ~ '^session \d+$'
+    AND s.id = substring(j.memo from '^session (\d+)$')::integer;
+
+UPDATE transactions t
+SET deposit_id = d.id
+FROM ledger_journals j, payment_deposits d
+WHERE j.id = t.journal_id AND j.memo = 'deposit ' || d.transaction_ref;
+
+UPDATE transactions t
+SET withdrawal_id = substring(j.memo from '^withdrawal request (\d+)$')::integer
+FROM ledger_journals j
+WHERE j.id = t.journal_id AND j.memo ~ '^withdrawal request \d+$';
+
+-- Transaction history is paged newest first by ID
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions(user_id, id DESC);
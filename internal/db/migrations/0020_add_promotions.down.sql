@@ -0,0 +1,9 @@
+ALTER TABLE reconciliation_discrepancies DROP CONSTRAINT IF EXISTS reconciliation_discrepancies_kind_check;
+DELETE FROM reconciliation_discrepancies WHERE kind = 'bonus';
+ALTER TABLE reconciliation_discrepancies ADD CONSTRAINT reconciliation_discrepancies_kind_check
+    CHECK (kind IN ('wallet', 'room'));
+ALTER TABLE transactions DROP COLUMN IF EXISTS balance;
+ALTER TABLE user_bets DROP COLUMN IF EXISTS bonus_amount;
+ALTER TABLE wallets DROP COLUMN IF EXISTS bonus_balance;
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_codes;
@@ -0,0 +1,51 @@
+-- Promo codes credit a bonus: a fixed amount, or a share of the next deposit
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('fixed', 'deposit_match')),
+    amount NUMERIC(18,2) NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    match_bps INTEGER NOT NULL DEFAULT 0 CHECK (match_bps BETWEEN 0 AND 100000),
+    max_bonus NUMERIC(18,2) CHECK (max_bonus > 0),
+    -- The bonus must be staked this many times before it can be withdrawn
+    wagering_multiplier INTEGER NOT NULL DEFAULT 0 CHECK (wagering_multiplier >= 0),
+    expires_at TIMESTAMPTZ,
+    max_redemptions INTEGER CHECK (max_redemptions > 0),
+    redemptions INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INTEGER NOT NULL REFERENCES promo_codes(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status VARCHAR(16) NOT NULL
+        CHECK (status IN ('pending_deposit', 'active', 'completed', 'forfeited')),
+    deposit_id INTEGER REFERENCES payment_deposits(id),
+    bonus_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    wagering_required NUMERIC(18,2) NOT NULL DEFAULT 0,
+    wagered NUMERIC(18,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    UNIQUE (promo_code_id, user_id)
+);
+
+-- A user works through one bonus at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_redemptions_open
+    ON promo_redemptions(user_id) WHERE status IN ('pending_deposit', 'active');
+
+-- Bonus funds are kept apart from cash; bonus_balance caches the user_bonus account
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS bonus_balance NUMERIC(18,2) NOT NULL DEFAULT 0;
+
+-- The part of a stake paid from the bonus balance, returned there on refund
+ALTER TABLE user_bets ADD COLUMN IF NOT EXISTS bonus_amount NUMERIC(18,2) NOT NULL DEFAULT 0;
+
+-- Which balance a transaction moved
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS balance VARCHAR(8) NOT NULL DEFAULT 'cash'
+    CHECK (balance IN ('cash', 'bonus'));
+
+ALTER TABLE reconciliation_discrepancies DROP CONSTRAINT IF EXISTS reconciliation_discrepancies_kind_check;
+ALTER TABLE reconciliation_discrepancies ADD CONSTRAINT reconciliation_discrepancies_kind_check
+    CHECK (kind IN ('wallet', 'bonus', 'room'));
@@ -0,0 +1,17 @@
+-- Who invited whom through the bot's referral link. Deposits and settled
+-- stakes of the referee are attributed to the referral; the rewards are paid
+-- once when the referee qualifies.
+CREATE TABLE IF NOT EXISTS referrals (
+    id SERIAL PRIMARY KEY,
+    referrer_id INTEGER NOT NULL REFERENCES users(id),
+    referee_id INTEGER NOT NULL UNIQUE REFERENCES users(id),
+    deposited NUMERIC(18,2) NOT NULL DEFAULT 0,
+    wagered NUMERIC(18,2) NOT NULL DEFAULT 0,
+    referrer_reward NUMERIC(18,2) NOT NULL DEFAULT 0,
+    referee_reward NUMERIC(18,2) NOT NULL DEFAULT 0,
+    qualified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (referrer_id <> referee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals(referrer_id);
@@ -0,0 +1,32 @@
+-- Responsible gaming limits a player sets on themselves over a rolling
+-- period. Deposit and loss limits are amounts, play time limits are minutes.
+-- A raised or removed limit waits in pending_* until pending_at.
+CREATE TABLE IF NOT EXISTS gaming_limits (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('deposit', 'loss', 'play_time')),
+    period VARCHAR(8) NOT NULL CHECK (period IN ('daily', 'weekly', 'monthly')),
+    amount NUMERIC(18,2) CHECK (amount >= 0),
+    minutes INTEGER CHECK (minutes >= 0),
+    pending_amount NUMERIC(18,2) CHECK (pending_amount >= 0),
+    pending_minutes INTEGER CHECK (pending_minutes >= 0),
+    pending_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, kind, period),
+    CHECK ((kind = 'play_time') = (minutes IS NOT NULL) AND (kind = 'play_time') <> (amount IS NOT NULL))
+);
+
+-- A self-excluded player cannot deposit or play until ends_at
+CREATE TABLE IF NOT EXISTS self_exclusions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMPTZ NOT NULL,
+    -- Set when the player asked to end the exclusion early
+    end_requested_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_self_exclusions_user ON self_exclusions(user_id, ends_at DESC);
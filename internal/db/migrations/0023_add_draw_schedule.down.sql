@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_game_sessions_next_draw;
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS next_draw_at;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS draw_interval_seconds;
@@ -0,0 +1,9 @@
+-- Numbers are drawn by the server every draw_interval_seconds of the room.
+-- next_draw_at keeps the schedule across restarts.
+ALTER TABLE bingo_rooms ADD COLUMN IF NOT EXISTS draw_interval_seconds INTEGER NOT NULL DEFAULT 5
+    CHECK (draw_interval_seconds > 0);
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS next_draw_at TIMESTAMPTZ;
+
+UPDATE game_sessions SET next_draw_at = NOW() WHERE status = 'active';
+
+CREATE INDEX IF NOT EXISTS idx_game_sessions_next_draw ON game_sessions(next_draw_at) WHERE status = 'active';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_bingo_rooms_status;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS recycled_at;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS completed_at;
@@ -0,0 +1,12 @@
+-- A room is completed once its session ends and recycled, replaced by a
+-- waiting room for the same bet amount, a while later
+ALTER TABLE bingo_rooms ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+ALTER TABLE bingo_rooms ADD COLUMN IF NOT EXISTS recycled_at TIMESTAMPTZ;
+
+-- Rooms whose session already ended are completed now and recycled by the worker
+UPDATE bingo_rooms r SET status = 'completed', completed_at = NOW()
+WHERE r.status = 'active'
+    AND EXISTS (SELECT 1 FROM game_sessions s WHERE s.room_id = r.id)
+    AND NOT EXISTS (SELECT 1 FROM game_sessions s WHERE s.room_id = r.id AND s.status = 'active');
+
+CREATE INDEX IF NOT EXISTS idx_bingo_rooms_status ON bingo_rooms(status);
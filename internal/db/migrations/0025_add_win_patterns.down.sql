@@ -0,0 +1,3 @@
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS win_pattern;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS win_pattern;
+DROP TABLE IF EXISTS win_patterns;
@@ -0,0 +1,14 @@
+-- Custom win patterns uploaded by operators; masks is a list of 5x5 grids of
+-- cells to cover. The built-in patterns live in the code.
+CREATE TABLE IF NOT EXISTS win_patterns (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    masks JSONB NOT NULL,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rooms name the pattern their games are played to; a session keeps a copy
+-- of it, so later changes to the room or pattern leave a game in progress alone
+ALTER TABLE bingo_rooms ADD COLUMN IF NOT EXISTS win_pattern VARCHAR(32) NOT NULL DEFAULT 'line';
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS win_pattern JSONB;
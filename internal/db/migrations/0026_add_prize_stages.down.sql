@@ -0,0 +1,9 @@
+ALTER TABLE bingo_claims DROP CONSTRAINT IF EXISTS bingo_claims_session_id_stage_user_id_key;
+DELETE FROM bingo_claims WHERE stage > 1;
+ALTER TABLE bingo_claims ADD CONSTRAINT bingo_claims_session_id_user_id_key UNIQUE (session_id, user_id);
+ALTER TABLE bingo_claims DROP COLUMN IF EXISTS stage;
+ALTER TABLE winners DROP COLUMN IF EXISTS stage;
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS pot;
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS current_stage;
+ALTER TABLE game_sessions DROP COLUMN IF EXISTS prize_stages;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS prize_stages;
@@ -0,0 +1,19 @@
+-- Rooms may split their pot into ordered prize stages, each won with its own
+-- pattern, e.g. one line, two lines, full house. NULL is a single stage won
+-- with the room's win_pattern.
+ALTER TABLE bingo_rooms ADD COLUMN IF NOT EXISTS prize_stages JSONB;
+
+-- A session keeps a copy of its stages and plays them in order; win_pattern
+-- is that of the live stage. pot is what has been gathered into the session
+-- so far: the carried pot and the stakes settled at each stage.
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS prize_stages JSONB;
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS current_stage INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE game_sessions ADD COLUMN IF NOT EXISTS pot NUMERIC(18,2) NOT NULL DEFAULT 0;
+
+UPDATE game_sessions SET pot = carried_pot WHERE status = 'active';
+
+-- Claims and winners belong to a stage; a player may win more than one
+ALTER TABLE winners ADD COLUMN IF NOT EXISTS stage INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE bingo_claims ADD COLUMN IF NOT EXISTS stage INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE bingo_claims DROP CONSTRAINT IF EXISTS bingo_claims_session_id_user_id_key;
+ALTER TABLE bingo_claims ADD CONSTRAINT bingo_claims_session_id_stage_user_id_key UNIQUE (session_id, stage, user_id);
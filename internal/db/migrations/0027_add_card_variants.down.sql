@@ -0,0 +1,7 @@
+UPDATE bingo_cards SET card_data = card_data - 'variant' - 'strip' WHERE card_data IS NOT NULL;
+UPDATE available_cards SET card_data = card_data - 'variant' - 'strip' WHERE card_data IS NOT NULL;
+DELETE FROM win_patterns WHERE variant <> '75_ball';
+ALTER TABLE win_patterns DROP CONSTRAINT IF EXISTS win_patterns_variant_name_key;
+ALTER TABLE win_patterns ADD CONSTRAINT win_patterns_name_key UNIQUE (name);
+ALTER TABLE win_patterns DROP COLUMN IF EXISTS variant;
+ALTER TABLE bingo_rooms DROP COLUMN IF EXISTS variant;
@@ -0,0 +1,16 @@
+-- Rooms pick the kind of bingo they play: 75-ball 5x5 cards or 90-ball 3x9
+-- tickets dealt in strips of six
+ALTER TABLE bingo_rooms ADD COLUMN IF NOT EXISTS variant VARCHAR(16) NOT NULL DEFAULT '75_ball'
+    CHECK (variant IN ('75_ball', '90_ball'));
+
+-- Custom patterns are drawn for the cards of one variant; names are unique
+-- within a variant
+ALTER TABLE win_patterns ADD COLUMN IF NOT EXISTS variant VARCHAR(16) NOT NULL DEFAULT '75_ball';
+ALTER TABLE win_patterns DROP CONSTRAINT IF EXISTS win_patterns_name_key;
+ALTER TABLE win_patterns ADD CONSTRAINT win_patterns_variant_name_key UNIQUE (variant, name);
+
+-- Card data names its variant; every card so far is a 75-ball card
+UPDATE available_cards SET card_data = card_data || '{"variant":"75_ball"}'::jsonb
+WHERE card_data IS NOT NULL AND NOT card_data ? 'variant';
+UPDATE bingo_cards SET card_data = card_data || '{"variant":"75_ball"}'::jsonb
+WHERE card_data IS NOT NULL AND NOT card_data ? 'variant';
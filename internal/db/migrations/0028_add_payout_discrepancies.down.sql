@@ -0,0 +1,9 @@
+DELETE FROM reconciliation_discrepancies WHERE kind = 'payout';
+DROP INDEX IF EXISTS idx_reconciliation_discrepancies_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_open
+    ON reconciliation_discrepancies(kind, COALESCE(user_id, 0), COALESCE(room_id, 0))
+    WHERE resolved_at IS NULL;
+ALTER TABLE reconciliation_discrepancies DROP CONSTRAINT IF EXISTS reconciliation_discrepancies_kind_check;
+ALTER TABLE reconciliation_discrepancies ADD CONSTRAINT reconciliation_discrepancies_kind_check
+    CHECK (kind IN ('wallet', 'bonus', 'room'));
+ALTER TABLE reconciliation_discrepancies DROP COLUMN IF EXISTS withdrawal_id;
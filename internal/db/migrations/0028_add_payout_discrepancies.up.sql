@@ -0,0 +1,12 @@
+-- A payout the provider completed for a withdrawal already failed (its funds
+-- back in the wallet) is recorded as a discrepancy against the withdrawal
+ALTER TABLE reconciliation_discrepancies ADD COLUMN IF NOT EXISTS withdrawal_id INTEGER REFERENCES withdrawal_requests(id);
+
+ALTER TABLE reconciliation_discrepancies DROP CONSTRAINT IF EXISTS reconciliation_discrepancies_kind_check;
+ALTER TABLE reconciliation_discrepancies ADD CONSTRAINT reconciliation_discrepancies_kind_check
+    CHECK (kind IN ('wallet', 'bonus', 'room', 'payout'));
+
+DROP INDEX IF EXISTS idx_reconciliation_discrepancies_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_open
+    ON reconciliation_discrepancies(kind, COALESCE(user_id, 0), COALESCE(room_id, 0), COALESCE(withdrawal_id, 0))
+    WHERE resolved_at IS NULL;
@@ -0,0 +1,4 @@
+-- The jackpot, fed by the pots of jackpot rooms nobody won, is paid out to
+-- the winners of the next jackpot room game; each winner's part is recorded
+-- next to their prize
+ALTER TABLE winners ADD COLUMN IF NOT EXISTS jackpot NUMERIC(18,2) NOT NULL DEFAULT 0;
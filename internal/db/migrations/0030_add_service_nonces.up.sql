@@ -0,0 +1,8 @@
+-- Nonces of signed bot requests, kept until their timestamp is too old to
+-- verify so a captured request can't be replayed
+CREATE TABLE IF NOT EXISTS service_nonces (
+    nonce VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_nonces_expires_at ON service_nonces (expires_at);
@@ -0,0 +1,17 @@
+-- Amounts are stored unsigned; the direction says whether the balance went
+-- up or down. Stakes already bet that move on from the escrow change no
+-- balance and have none.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS direction VARCHAR(6) NOT NULL DEFAULT 'none'
+    CHECK (direction IN ('credit', 'debit', 'none'));
+
+UPDATE transactions t
+SET direction = CASE WHEN e.amount < 0 THEN 'debit' ELSE 'credit' END
+FROM ledger_entries e
+JOIN ledger_accounts a ON a.id = e.account_id
+WHERE e.journal_id = t.journal_id
+    AND a.user_id = t.user_id
+    AND a.kind = CASE t.balance WHEN 'bonus' THEN 'user_bonus' ELSE 'user_wallet' END;
+
+UPDATE transactions
+SET direction = CASE WHEN type IN ('bet', 'withdraw') THEN 'debit' ELSE 'credit' END
+WHERE journal_id IS NULL;
@@ -7,25 +7,46 @@ import (
 
 // Users table
 type User struct {
-	ID         int64     `db:"id"         json:"id"`
-	TelegramID int64     `db:"telegram_id" json:"telegram_id"`
-	Username   string    `db:"username"    json:"username"`
-	FirstName  string    `db:"first_name"  json:"first_name"`
-	LastName   string    `db:"last_name"   json:"last_name"`
-	CreatedAt  time.Time `db:"created_at"  json:"created_at"`
+	ID           int64     `db:"id"            json:"id"`
+	TelegramID   int64     `db:"telegram_id"   json:"telegram_id"`
+	Username     string    `db:"username"      json:"username"`
+	FirstName    string    `db:"first_name"    json:"first_name"`
+	LastName     string    `db:"last_name"     json:"last_name"`
+	TokenVersion int       `db:"token_version" json:"-"`
+	Role         string    `db:"role"          json:"role"`
+	CreatedAt    time.Time `db:"created_at"    json:"created_at"`
 }
 
 // BingoRooms table
 type BingoRoom struct {
-	ID             int64      `db:"id"              json:"id"`
-	BetAmount      float64    `db:"bet_amount"      json:"bet_amount"`
-	CurrentPlayers int        `db:"current_players" json:"current_players"`
-	MaxPlayers     int        `db:"max_players"     json:"max_players"`
-	Status         string     `db:"status"          json:"status"`
-	CountdownStart *time.Time `db:"countdown_start" json:"countdown_start"`
-	GameStartTime  *time.Time `db:"game_start_time" json:"game_start_time"`
-	CreatedAt      time.Time  `db:"created_at"      json:"created_at"`
-	UpdatedAt      time.Time  `db:"updated_at"      json:"updated_at"`
+	ID             int64      `db:"id"               json:"id"`
+	BetAmount      Money      `db:"bet_amount"       json:"bet_amount"`
+	CurrentPlayers int        `db:"current_players"  json:"current_players"`
+	MaxPlayers     int        `db:"max_players"      json:"max_players"`
+	Status         string     `db:"status"           json:"status"`
+	CountdownStart *time.Time `db:"countdown_start"  json:"countdown_start"`
+	GameStartTime  *time.Time `db:"game_start_time"  json:"game_start_time"`
+	TierID         *int64     `db:"tier_id"          json:"tier_id"`
+	NoWinnerPolicy string     `db:"no_winner_policy" json:"no_winner_policy"`
+	CreatedAt      time.Time  `db:"created_at"       json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"       json:"updated_at"`
+	RakeConfig
+	DrawIntervalSeconds int             `db:"draw_interval_seconds" json:"draw_interval_seconds"`
+	CompletedAt         *time.Time      `db:"completed_at"          json:"completed_at"`
+	RecycledAt          *time.Time      `db:"recycled_at"           json:"recycled_at"`
+	WinPattern          string          `db:"win_pattern"           json:"win_pattern"`
+	PrizeStages         json.RawMessage `db:"prize_stages"          json:"prize_stages"`
+	Variant             string          `db:"variant"               json:"variant"`
+}
+
+// RoomTiers table
+type RoomTier struct {
+	ID             int64     `db:"id"               json:"id"`
+	BetAmount      Money     `db:"bet_amount"       json:"bet_amount"`
+	NoWinnerPolicy string    `db:"no_winner_policy" json:"no_winner_policy"`
+	CreatedAt      time.Time `db:"created_at"       json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"       json:"updated_at"`
+	RakeConfig
 }
 
 type Room = BingoRoom
@@ -68,7 +89,16 @@ type GameSession struct {
 	Status           string          `db:"status"              json:"status"`
 	DrawnNumbers     json.RawMessage `db:"drawn_numbers"       json:"drawn_numbers"`
 	RemainingNumbers json.RawMessage `db:"remaining_numbers"   json:"remaining_numbers"`
+	CarriedPot       Money           `db:"carried_pot"         json:"carried_pot"`
+	Outcome          *string         `db:"outcome"             json:"outcome"`
+	OutcomeAmount    *Money          `db:"outcome_amount"      json:"outcome_amount"`
+	ClaimDeadline    *time.Time      `db:"claim_deadline"      json:"claim_deadline"`
 	CreatedAt        time.Time       `db:"created_at"          json:"created_at"`
+	NextDrawAt       *time.Time      `db:"next_draw_at"        json:"next_draw_at"`
+	WinPattern       json.RawMessage `db:"win_pattern"         json:"win_pattern"`
+	PrizeStages      json.RawMessage `db:"prize_stages"        json:"prize_stages"`
+	CurrentStage     int             `db:"current_stage"       json:"current_stage"`
+	Pot              Money           `db:"pot"                 json:"pot"`
 }
 
 // GameNumbers table
@@ -84,49 +114,246 @@ type Winner struct {
 	ID          int64     `db:"id"            json:"id"`
 	SessionID   int64     `db:"session_id"    json:"session_id"`
 	UserID      int64     `db:"user_id"       json:"user_id"`
-	BingoCardID int64     `db:"bingo_card_id" json:"bingo_card_id"`
-	Winnings    float64   `db:"winnings"      json:"winnings"`
+	BingoCardID *int64    `db:"bingo_card_id" json:"bingo_card_id"`
+	Winnings    Money     `db:"winnings"      json:"winnings"`
+	Rake        Money     `db:"rake"          json:"rake"`
 	WonAt       time.Time `db:"won_at"        json:"won_at"`
+	Stage       int       `db:"stage"         json:"stage"`
+	Jackpot     Money     `db:"jackpot"       json:"jackpot"`
 }
 
-// UserBets table
-type UserBet struct {
+// BingoClaims table
+type BingoClaim struct {
 	ID          int64     `db:"id"            json:"id"`
+	SessionID   int64     `db:"session_id"    json:"session_id"`
 	UserID      int64     `db:"user_id"       json:"user_id"`
-	RoomID      int64     `db:"room_id"       json:"room_id"`
-	BingoCardID int64     `db:"bingo_card_id" json:"bingo_card_id"`
-	BetAmount   float64   `db:"bet_amount"    json:"bet_amount"`
-	CreatedAt   time.Time `db:"created_at"    json:"created_at"`
+	BingoCardID *int64    `db:"bingo_card_id" json:"bingo_card_id"`
+	CardNumber  int       `db:"card_number"   json:"card_number"`
+	DrawCount   int       `db:"draw_count"    json:"draw_count"`
+	ClaimedAt   time.Time `db:"claimed_at"    json:"claimed_at"`
+	Stage       int       `db:"stage"         json:"stage"`
+}
+
+// UserBets table
+type UserBet struct {
+	ID          int64      `db:"id"            json:"id"`
+	UserID      int64      `db:"user_id"       json:"user_id"`
+	RoomID      int64      `db:"room_id"       json:"room_id"`
+	BingoCardID *int64     `db:"bingo_card_id" json:"bingo_card_id"`
+	BetAmount   Money      `db:"bet_amount"    json:"bet_amount"`
+	BonusAmount Money      `db:"bonus_amount"  json:"bonus_amount"`
+	Status      string     `db:"status"        json:"status"`
+	SettledAt   *time.Time `db:"settled_at"    json:"settled_at"`
+	CreatedAt   time.Time  `db:"created_at"    json:"created_at"`
 }
 
 // Wallets table
 type Wallet struct {
-	ID        int64     `db:"id"         json:"id"`
-	UserID    int64     `db:"user_id"    json:"user_id"`
-	Balance   float64   `db:"balance"    json:"balance"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID           int64      `db:"id"            json:"id"`
+	UserID       int64      `db:"user_id"       json:"user_id"`
+	Balance      Money      `db:"balance"       json:"balance"`
+	CreatedAt    time.Time  `db:"created_at"    json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at"    json:"updated_at"`
+	FrozenAt     *time.Time `db:"frozen_at"     json:"frozen_at"`
+	FrozenReason *string    `db:"frozen_reason" json:"frozen_reason"`
+	BonusBalance Money      `db:"bonus_balance" json:"bonus_balance"`
 }
 
 // Transactions table
 type Transaction struct {
-	ID        int64     `db:"id"         json:"id"`
-	UserID    int64     `db:"user_id"    json:"user_id"`
-	Type      string    `db:"type"       json:"type"`
-	Amount    float64   `db:"amount"     json:"amount"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID           int64     `db:"id"            json:"id"`
+	UserID       int64     `db:"user_id"       json:"user_id"`
+	Type         string    `db:"type"          json:"type"`
+	Amount       Money     `db:"amount"        json:"amount"`
+	JournalID    *int64    `db:"journal_id"    json:"journal_id,omitempty"`
+	RoomID       *int64    `db:"room_id"       json:"room_id,omitempty"`
+	SessionID    *int64    `db:"session_id"    json:"session_id,omitempty"`
+	DepositID    *int64    `db:"deposit_id"    json:"deposit_id,omitempty"`
+	WithdrawalID *int64    `db:"withdrawal_id" json:"withdrawal_id,omitempty"`
+	Balance      string    `db:"balance"       json:"balance"`
+	Direction    string    `db:"direction"     json:"direction"`
+	CreatedAt    time.Time `db:"created_at"    json:"created_at"`
 }
 
 // PaymentDeposits table
 type PaymentDeposit struct {
-	ID             int64     `db:"id"              json:"id"`
-	UserID         int64     `db:"user_id"         json:"user_id"`
-	Amount         float64   `db:"amount"          json:"amount"`
-	Currency       string    `db:"currency"        json:"currency"`
-	TransactionRef string    `db:"transaction_ref" json:"transaction_ref"`
-	Status         string    `db:"status"          json:"status"`
-	CreatedAt      time.Time `db:"created_at"      json:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"      json:"updated_at"`
+	ID             int64      `db:"id"              json:"id"`
+	UserID         int64      `db:"user_id"         json:"user_id"`
+	Amount         Money      `db:"amount"          json:"amount"`
+	Currency       string     `db:"currency"        json:"currency"`
+	TransactionRef string     `db:"transaction_ref" json:"transaction_ref"`
+	Status         string     `db:"status"          json:"status"`
+	CreatedAt      time.Time  `db:"created_at"      json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"      json:"updated_at"`
+	Provider       *string    `db:"provider"        json:"provider"`
+	ProviderRef    *string    `db:"provider_ref"    json:"provider_ref"`
+	CheckoutURL    *string    `db:"checkout_url"    json:"checkout_url"`
+	CompletedAt    *time.Time `db:"completed_at"    json:"completed_at"`
+}
+
+// WithdrawalRequests table
+type WithdrawalRequest struct {
+	ID          int64      `db:"id"          json:"id"`
+	UserID      int64      `db:"user_id"     json:"user_id"`
+	Amount      Money      `db:"amount"      json:"amount"`
+	Currency    string     `db:"currency"    json:"currency"`
+	Destination string     `db:"destination" json:"destination"`
+	Status      string     `db:"status"      json:"status"`
+	Note        *string    `db:"note"        json:"note"`
+	ReviewedBy  *int64     `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedAt  *time.Time `db:"reviewed_at" json:"reviewed_at"`
+	CreatedAt   time.Time  `db:"created_at"  json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"  json:"updated_at"`
+	// Payout through the payout provider
+	PayoutMethodID *int64     `db:"payout_method_id" json:"payout_method_id"`
+	Provider       *string    `db:"provider"         json:"provider"`
+	ProviderRef    *string    `db:"provider_ref"     json:"provider_ref"`
+	PayoutAttempts int        `db:"payout_attempts"  json:"payout_attempts"`
+	NextAttemptAt  *time.Time `db:"next_attempt_at"  json:"next_attempt_at"`
+	LastError      *string    `db:"last_error"       json:"last_error"`
+}
+
+// ReconciliationDiscrepancies table
+type Discrepancy struct {
+	ID             int64      `db:"id"              json:"id"`
+	Kind           string     `db:"kind"            json:"kind"`
+	UserID         *int64     `db:"user_id"         json:"user_id"`
+	RoomID         *int64     `db:"room_id"         json:"room_id"`
+	WithdrawalID   *int64     `db:"withdrawal_id"   json:"withdrawal_id"`
+	Expected       Money      `db:"expected"        json:"expected"`
+	Actual         Money      `db:"actual"          json:"actual"`
+	DetectedAt     time.Time  `db:"detected_at"     json:"detected_at"`
+	LastSeenAt     time.Time  `db:"last_seen_at"    json:"last_seen_at"`
+	ResolvedAt     *time.Time `db:"resolved_at"     json:"resolved_at"`
+	ResolvedBy     *int64     `db:"resolved_by"     json:"resolved_by"`
+	ResolutionNote *string    `db:"resolution_note" json:"resolution_note"`
+}
+
+// PayoutMethods table
+type PayoutMethod struct {
+	ID         int64      `db:"id"          json:"id"`
+	UserID     int64      `db:"user_id"     json:"user_id"`
+	MethodType string     `db:"method_type" json:"method_type"`
+	Account    string     `db:"account"     json:"account"`
+	BankCode   string     `db:"bank_code"   json:"bank_code"`
+	CreatedAt  time.Time  `db:"created_at"  json:"created_at"`
+	Label      *string    `db:"label"       json:"label"`
+	VerifiedAt *time.Time `db:"verified_at" json:"verified_at"`
+	VerifiedBy *int64     `db:"verified_by" json:"verified_by"`
+	RemovedAt  *time.Time `db:"removed_at"  json:"removed_at"`
+}
+
+// IdempotencyKeys table
+type IdempotencyKey struct {
+	ID           int64      `db:"id"            json:"id"`
+	UserID       int64      `db:"user_id"       json:"user_id"`
+	Key          string     `db:"key"           json:"key"`
+	Method       string     `db:"method"        json:"method"`
+	Path         string     `db:"path"          json:"path"`
+	RequestHash  string     `db:"request_hash"  json:"request_hash"`
+	StatusCode   *int       `db:"status_code"   json:"status_code"`
+	ContentType  *string    `db:"content_type"  json:"content_type"`
+	ResponseBody []byte     `db:"response_body" json:"-"`
+	CreatedAt    time.Time  `db:"created_at"    json:"created_at"`
+	CompletedAt  *time.Time `db:"completed_at"  json:"completed_at"`
+}
+
+// LedgerAccounts table
+type LedgerAccount struct {
+	ID        int64     `db:"id"         json:"id"`
+	Kind      string    `db:"kind"       json:"kind"`
+	UserID    *int64    `db:"user_id"    json:"user_id,omitempty"`
+	RoomID    *int64    `db:"room_id"    json:"room_id,omitempty"`
+	TierID    *int64    `db:"tier_id"    json:"tier_id,omitempty"`
+	Currency  string    `db:"currency"   json:"currency"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// PromoCodes table
+type PromoCode struct {
+	ID                 int64      `db:"id"                  json:"id"`
+	Code               string     `db:"code"                json:"code"`
+	Kind               string     `db:"kind"                json:"kind"`
+	Amount             Money      `db:"amount"              json:"amount"`
+	MatchBps           int        `db:"match_bps"           json:"match_bps"`
+	MaxBonus           *Money     `db:"max_bonus"           json:"max_bonus"`
+	WageringMultiplier int        `db:"wagering_multiplier" json:"wagering_multiplier"`
+	ExpiresAt          *time.Time `db:"expires_at"          json:"expires_at"`
+	MaxRedemptions     *int       `db:"max_redemptions"     json:"max_redemptions"`
+	Redemptions        int        `db:"redemptions"         json:"redemptions"`
+	Active             bool       `db:"active"              json:"active"`
+	CreatedBy          *int64     `db:"created_by"          json:"created_by"`
+	CreatedAt          time.Time  `db:"created_at"          json:"created_at"`
+}
+
+// PromoRedemptions table
+type PromoRedemption struct {
+	ID               int64      `db:"id"                json:"id"`
+	PromoCodeID      int64      `db:"promo_code_id"     json:"promo_code_id"`
+	UserID           int64      `db:"user_id"           json:"user_id"`
+	Status           string     `db:"status"            json:"status"`
+	DepositID        *int64     `db:"deposit_id"        json:"deposit_id"`
+	BonusAmount      Money      `db:"bonus_amount"      json:"bonus_amount"`
+	WageringRequired Money      `db:"wagering_required" json:"wagering_required"`
+	Wagered          Money      `db:"wagered"           json:"wagered"`
+	CreatedAt        time.Time  `db:"created_at"        json:"created_at"`
+	ActivatedAt      *time.Time `db:"activated_at"      json:"activated_at"`
+	CompletedAt      *time.Time `db:"completed_at"      json:"completed_at"`
+}
+
+// Referrals table
+type Referral struct {
+	ID             int64      `db:"id"              json:"id"`
+	ReferrerID     int64      `db:"referrer_id"     json:"referrer_id"`
+	RefereeID      int64      `db:"referee_id"      json:"referee_id"`
+	Deposited      Money      `db:"deposited"       json:"deposited"`
+	Wagered        Money      `db:"wagered"         json:"wagered"`
+	ReferrerReward Money      `db:"referrer_reward" json:"referrer_reward"`
+	RefereeReward  Money      `db:"referee_reward"  json:"referee_reward"`
+	QualifiedAt    *time.Time `db:"qualified_at"    json:"qualified_at"`
+	CreatedAt      time.Time  `db:"created_at"      json:"created_at"`
+}
+
+// GamingLimits table
+type GamingLimit struct {
+	ID             int64      `db:"id"              json:"id"`
+	UserID         int64      `db:"user_id"         json:"user_id"`
+	Kind           string     `db:"kind"            json:"kind"`
+	Period         string     `db:"period"          json:"period"`
+	Amount         *Money     `db:"amount"          json:"amount,omitempty"`
+	Minutes        *int       `db:"minutes"         json:"minutes,omitempty"`
+	PendingAmount  *Money     `db:"pending_amount"  json:"pending_amount,omitempty"`
+	PendingMinutes *int       `db:"pending_minutes" json:"pending_minutes,omitempty"`
+	PendingAt      *time.Time `db:"pending_at"      json:"pending_at"`
+	CreatedAt      time.Time  `db:"created_at"      json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"      json:"updated_at"`
+}
+
+// SelfExclusions table
+type SelfExclusion struct {
+	ID             int64      `db:"id"               json:"id"`
+	UserID         int64      `db:"user_id"          json:"user_id"`
+	StartsAt       time.Time  `db:"starts_at"        json:"starts_at"`
+	EndsAt         time.Time  `db:"ends_at"          json:"ends_at"`
+	EndRequestedAt *time.Time `db:"end_requested_at" json:"end_requested_at"`
+	CreatedAt      time.Time  `db:"created_at"       json:"created_at"`
+}
+
+// LedgerJournals table
+type LedgerJournal struct {
+	ID        int64     `db:"id"         json:"id"`
+	Kind      string    `db:"kind"       json:"kind"`
+	Memo      *string   `db:"memo"       json:"memo,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// LedgerEntries table
+type LedgerEntry struct {
+	ID        int64     `db:"id"         json:"id"`
+	JournalID int64     `db:"journal_id" json:"journal_id"`
+	AccountID int64     `db:"account_id" json:"account_id"`
+	Amount    Money     `db:"amount"     json:"amount"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
 // AuditLogs table
@@ -137,3 +364,13 @@ type AuditLog struct {
 	Details   json.RawMessage `db:"details"    json:"details"`
 	CreatedAt time.Time       `db:"created_at" json:"created_at"`
 }
+
+// WinPatterns table, the custom patterns uploaded by operators
+type WinPattern struct {
+	ID        int64           `db:"id"         json:"id"`
+	Name      string          `db:"name"       json:"name"`
+	Masks     json.RawMessage `db:"masks"      json:"masks"`
+	CreatedBy *int64          `db:"created_by" json:"created_by"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	Variant   string          `db:"variant"    json:"variant"`
+}
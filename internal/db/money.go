@@ -0,0 +1,220 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const CurrencyETB Currency = "ETB"
+
+// DefaultCurrency is the currency of every amount stored in the database
+const DefaultCurrency = CurrencyETB
+
+// minorPerMajor is the number of minor units (cents) per major unit. All
+// supported currencies use two decimal places, matching the NUMERIC(18,2) columns.
+const minorPerMajor = 100
+
+// ValidCurrency reports whether cur is a supported currency
+func ValidCurrency(cur Currency) bool {
+	return cur == CurrencyETB
+}
+
+// Money is an exact amount held as an integer number of minor units of a
+// currency. The zero value is zero in DefaultCurrency. Arithmetic between
+// different currencies is a programming error and panics.
+//
+// In JSON a Money is a decimal number with two fraction digits (12.50); in the
+// database it is a NUMERIC value.
+type Money struct {
+	Minor    int64
+	Currency Currency
+}
+
+// NewMoney returns minor units of cur
+func NewMoney(minor int64, cur Currency) Money {
+	return Money{Minor: minor, Currency: cur}
+}
+
+// ParseMoney parses a decimal amount such as "10", "-3.5" or "12.50". More than
+// two fraction digits is an error rather than being rounded.
+func ParseMoney(s string, cur Currency) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > 2 {
+		return Money{}, fmt.Errorf("amount %q has more than 2 decimal places", s)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return Money{}, fmt.Errorf("invalid amount %q", s)
+			}
+		}
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	minor, _ := strconv.ParseInt(frac, 10, 64)
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > (math.MaxInt64-minor)/minorPerMajor {
+		return Money{}, fmt.Errorf("amount %q out of range", s)
+	}
+	total := major*minorPerMajor + minor
+	if neg {
+		total = -total
+	}
+	return Money{Minor: total, Currency: cur}, nil
+}
+
+// Cur returns the currency, treating the zero value as DefaultCurrency
+func (m Money) Cur() Currency {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+func (m Money) mustMatch(o Money) {
+	if m.Cur() != o.Cur() {
+		panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.Cur(), o.Cur()))
+	}
+}
+
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Cur()}
+}
+
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return Money{Minor: m.Minor - o.Minor, Currency: m.Cur()}
+}
+
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Cur()}
+}
+
+// Mul returns m multiplied by n
+func (m Money) Mul(n int64) Money {
+	return Money{Minor: m.Minor * n, Currency: m.Cur()}
+}
+
+// MulBps returns m scaled by bps basis points (1/100 of a percent), rounded
+// toward zero to a whole minor unit. The product is taken exactly, so large
+// amounts and rates above 100% don't overflow on the way; a result beyond
+// the range of an int64 is clamped to it rather than wrapping around.
+func (m Money) MulBps(bps int64) Money {
+	n := new(big.Int).Mul(big.NewInt(m.Minor), big.NewInt(bps))
+	n.Quo(n, big.NewInt(10000))
+	switch {
+	case n.IsInt64():
+		return Money{Minor: n.Int64(), Currency: m.Cur()}
+	case n.Sign() > 0:
+		return Money{Minor: math.MaxInt64, Currency: m.Cur()}
+	}
+	return Money{Minor: math.MinInt64, Currency: m.Cur()}
+}
+
+// Split divides m into n equal shares, returning the share and the minor
+// units left over, which the caller has to allocate explicitly
+func (m Money) Split(n int64) (share Money, remainder Money) {
+	if n <= 0 {
+		panic("money: split into non-positive number of shares")
+	}
+	return Money{Minor: m.Minor / n, Currency: m.Cur()}, Money{Minor: m.Minor % n, Currency: m.Cur()}
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or greater than o
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.Minor < o.Minor:
+		return -1
+	case m.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+// Decimal formats the amount without currency, e.g. "12.50"
+func (m Money) Decimal() string {
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorPerMajor, minor%minorPerMajor)
+}
+
+// String formats the amount with its currency, e.g. "12.50 ETB"
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Cur())
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or string in DefaultCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return errors.New("amount must be a number")
+	}
+	parsed, err := ParseMoney(num.String(), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column in DefaultCurrency
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{Currency: DefaultCurrency}
+		return nil
+	case int64:
+		*m = Money{Minor: v * minorPerMajor, Currency: DefaultCurrency}
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	}
+	return fmt.Errorf("cannot scan %T into Money", src)
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s, DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount as a decimal string for a NUMERIC column
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
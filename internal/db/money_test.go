@@ -0,0 +1,104 @@
+package db
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		minor   int64
+		wantErr bool
+	}{
+		{in: "10", minor: 1000},
+		{in: "-3.5", minor: -350},
+		{in: "+12.50", minor: 1250},
+		{in: " 0.07 ", minor: 7},
+		{in: ".5", minor: 50},
+		{in: "7.", minor: 700},
+		{in: "92233720368547758.07", minor: 9223372036854775807},
+		{in: "-92233720368547758.07", minor: -9223372036854775807},
+		{in: "92233720368547758.08", wantErr: true},
+		{in: "92233720368547759", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+		{in: "1.234", wantErr: true},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "1,50", wantErr: true},
+		{in: "--1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, CurrencyETB)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got.Minor != tt.minor || got.Currency != CurrencyETB {
+			t.Errorf("ParseMoney(%q) = %d %s, want %d %s", tt.in, got.Minor, got.Currency, tt.minor, CurrencyETB)
+		}
+	}
+}
+
+func TestMoneySplit(t *testing.T) {
+	tests := []struct {
+		minor, n         int64
+		share, remainder int64
+	}{
+		{minor: 1000, n: 1, share: 1000, remainder: 0},
+		{minor: 1000, n: 3, share: 333, remainder: 1},
+		{minor: 1001, n: 4, share: 250, remainder: 1},
+		{minor: 2, n: 5, share: 0, remainder: 2},
+		{minor: 0, n: 7, share: 0, remainder: 0},
+		{minor: -1000, n: 3, share: -333, remainder: -1},
+	}
+	for _, tt := range tests {
+		share, remainder := NewMoney(tt.minor, CurrencyETB).Split(tt.n)
+		if share.Minor != tt.share || remainder.Minor != tt.remainder {
+			t.Errorf("Split(%d, %d) = %d, %d, want %d, %d", tt.minor, tt.n, share.Minor, remainder.Minor, tt.share, tt.remainder)
+		}
+		if share.Minor*tt.n+remainder.Minor != tt.minor {
+			t.Errorf("Split(%d, %d) loses money", tt.minor, tt.n)
+		}
+	}
+}
+
+func TestMoneySplitPanicsOnNoShares(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Split(0) did not panic")
+		}
+	}()
+	NewMoney(100, CurrencyETB).Split(0)
+}
+
+func TestMoneyMulBps(t *testing.T) {
+	tests := []struct {
+		minor, bps, want int64
+	}{
+		{minor: 10000, bps: 1000, want: 1000},
+		{minor: 10000, bps: 10000, want: 10000},
+		{minor: 10000, bps: 0, want: 0},
+		{minor: 999, bps: 1000, want: 99},
+		{minor: 1, bps: 9999, want: 0},
+		{minor: 12345, bps: 250, want: 308},
+		{minor: -999, bps: 1000, want: -99},
+		{minor: 10000, bps: 100000, want: 100000},
+		{minor: 9223372036854775807, bps: 5000, want: 4611686018427387903},
+		{minor: 922337203685477580, bps: 100000, want: 9223372036854775800},
+		{minor: -9223372036854775807, bps: 10000, want: -9223372036854775807},
+		{minor: 9223372036854775807, bps: 100000, want: 9223372036854775807},
+		{minor: -9223372036854775807, bps: 100000, want: -9223372036854775808},
+	}
+	for _, tt := range tests {
+		got := NewMoney(tt.minor, CurrencyETB).MulBps(tt.bps)
+		if got.Minor != tt.want {
+			t.Errorf("MulBps(%d, %d) = %d, want %d", tt.minor, tt.bps, got.Minor, tt.want)
+		}
+	}
+}
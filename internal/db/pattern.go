@@ -0,0 +1,260 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"rockbingo/internal/game"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrPatternNotFound is returned for a pattern that is neither built in nor uploaded
+	ErrPatternNotFound = errors.New("win pattern not found")
+	// ErrDuplicatePattern is returned when uploading a pattern whose name is taken
+	ErrDuplicatePattern = errors.New("win pattern already exists")
+	// ErrPatternInUse is returned when deleting a pattern a waiting room plays to
+	ErrPatternInUse = errors.New("win pattern is used by a waiting room")
+)
+
+// RoomRules is what a player needs to know about how a room's game is played
+type RoomRules struct {
+	RoomID              int64        `json:"room_id"`
+	Variant             string       `json:"variant"`
+	SessionID           *int64       `json:"session_id"`
+	BetAmount           Money        `json:"bet_amount"`
+	WinPattern          game.Pattern `json:"win_pattern"`
+	PrizeStages         []PrizeStage `json:"prize_stages"`
+	CurrentStage        int          `json:"current_stage"`
+	DrawIntervalSeconds int          `json:"draw_interval_seconds"`
+	ClaimWindowSeconds  int          `json:"claim_window_seconds"`
+	RakeConfig
+}
+
+type PatternStore struct {
+	DB *sqlx.DB
+}
+
+func NewPatternStore(db *sqlx.DB) *PatternStore {
+	return &PatternStore{DB: db}
+}
+
+// List the built-in patterns followed by the custom ones
+func (s *PatternStore) ListPatterns(ctx context.Context) ([]game.Pattern, error) {
+	var rows []WinPattern
+	if err := s.DB.SelectContext(ctx, &rows, `SELECT * FROM win_patterns ORDER BY variant, name`); err != nil {
+		return nil, err
+	}
+	patterns := game.BuiltInPatterns()
+	for _, row := range rows {
+		p, err := row.Pattern()
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// Get a built-in or custom pattern of a variant by name
+func (s *PatternStore) GetPattern(ctx context.Context, variant, name string) (*game.Pattern, error) {
+	p, err := lookupPattern(ctx, s.DB, variant, name)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Upload a custom pattern, checked with game.NewPattern
+func (s *PatternStore) CreatePattern(ctx context.Context, p game.Pattern, createdBy int64) (*game.Pattern, error) {
+	masks, err := json.Marshal(p.Masks)
+	if err != nil {
+		return nil, err
+	}
+	var row WinPattern
+	err = s.DB.GetContext(ctx, &row, `
+		INSERT INTO win_patterns (name, variant, masks, created_by) VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`, p.Name, p.VariantName(), masks, createdBy)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicatePattern
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved, err := row.Pattern()
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// Delete a custom pattern no waiting room plays to, as its pattern or that
+// of a prize stage. Sessions keep their own copy of their patterns, so games
+// already started are not affected.
+func (s *PatternStore) DeletePattern(ctx context.Context, variant, name string) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.GetContext(ctx, &id, `SELECT id FROM win_patterns WHERE variant = $1 AND name = $2 FOR UPDATE`, variant, name)
+	if err == sql.ErrNoRows {
+		return ErrPatternNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var inUse bool
+	err = tx.GetContext(ctx, &inUse, `
+		SELECT EXISTS (
+			SELECT 1 FROM bingo_rooms
+			WHERE status = 'waiting' AND variant = $1
+				AND (win_pattern = $2 OR prize_stages @> jsonb_build_array(jsonb_build_object('pattern', $2::text)))
+		)
+	`, variant, name)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrPatternInUse
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM win_patterns WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Pattern returns the custom pattern stored in the row
+func (w *WinPattern) Pattern() (game.Pattern, error) {
+	p := game.Pattern{Name: w.Name, Variant: w.Variant}
+	if err := json.Unmarshal(w.Masks, &p.Masks); err != nil {
+		return game.Pattern{}, err
+	}
+	return p, nil
+}
+
+// lookupPattern returns the built-in or custom pattern of a variant with the
+// given name. Custom patterns are read with a share lock so they can't be
+// deleted while a room is set to them or a session starts with them.
+func lookupPattern(ctx context.Context, q sqlx.QueryerContext, variant, name string) (game.Pattern, error) {
+	if p, ok := game.BuiltInPattern(variant, name); ok {
+		return p, nil
+	}
+	var row WinPattern
+	err := sqlx.GetContext(ctx, q, &row, `
+		SELECT * FROM win_patterns WHERE variant = $1 AND name = $2 FOR SHARE
+	`, variant, name)
+	if err == sql.ErrNoRows {
+		return game.Pattern{}, ErrPatternNotFound
+	}
+	if err != nil {
+		return game.Pattern{}, err
+	}
+	return row.Pattern()
+}
+
+// sessionPattern returns the pattern a session is played to. Sessions
+// started before patterns were configurable are played to a line.
+func sessionPattern(session *GameSession) (game.Pattern, error) {
+	if len(session.WinPattern) == 0 {
+		return game.LinePattern(), nil
+	}
+	var p game.Pattern
+	if err := json.Unmarshal(session.WinPattern, &p); err != nil {
+		return game.Pattern{}, err
+	}
+	return p, nil
+}
+
+// Set the pattern of a room that has not started yet. The room's game
+// becomes a single prize stage won with it.
+func (s *RoomStore) SetRoomPattern(ctx context.Context, roomID int64, name string) (*BingoRoom, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	variant, err := roomVariant(ctx, tx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := lookupPattern(ctx, tx, variant, name); err != nil {
+		return nil, err
+	}
+	var room BingoRoom
+	err = tx.GetContext(ctx, &room, `
+		UPDATE bingo_rooms SET win_pattern = $2, prize_stages = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'waiting'
+		RETURNING *
+	`, roomID, name)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomNotWaiting
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// roomVariant returns the variant of a room that has not started yet
+func roomVariant(ctx context.Context, q sqlx.QueryerContext, roomID int64) (string, error) {
+	var variant string
+	err := sqlx.GetContext(ctx, q, &variant, `
+		SELECT variant FROM bingo_rooms WHERE id = $1 AND status = 'waiting'
+	`, roomID)
+	if err == sql.ErrNoRows {
+		return "", ErrRoomNotWaiting
+	}
+	return variant, err
+}
+
+// Get the rules of a room. The prize stages are those of the room's active
+// session if one is running, with the live stage's pattern, otherwise those
+// the next game will be played with.
+func (s *RoomStore) GetRoomRules(ctx context.Context, roomID int64) (*RoomRules, error) {
+	room, err := s.GetRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	rules := RoomRules{
+		RoomID:              room.ID,
+		Variant:             room.Variant,
+		BetAmount:           room.BetAmount,
+		DrawIntervalSeconds: room.DrawIntervalSeconds,
+		ClaimWindowSeconds:  int(roomConfig.ClaimWindow / time.Second),
+		RakeConfig:          room.RakeConfig,
+	}
+
+	var session GameSession
+	err = s.DB.GetContext(ctx, &session, `
+		SELECT * FROM game_sessions WHERE room_id = $1 AND status = 'active' ORDER BY created_at DESC LIMIT 1
+	`, roomID)
+	switch {
+	case err == nil:
+		rules.SessionID = &session.ID
+		rules.CurrentStage = session.CurrentStage
+		rules.PrizeStages, err = sessionStages(&session)
+	case err == sql.ErrNoRows:
+		rules.CurrentStage = 1
+		rules.PrizeStages, err = resolveStages(ctx, s.DB, room)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rules.CurrentStage < 1 || rules.CurrentStage > len(rules.PrizeStages) {
+		return nil, fmt.Errorf("room %d has no stage %d", roomID, rules.CurrentStage)
+	}
+	rules.WinPattern = rules.PrizeStages[rules.CurrentStage-1].Pattern
+	return &rules, nil
+}
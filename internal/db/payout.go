@@ -0,0 +1,278 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Payout method types
+const (
+	PayoutMobileMoney = "mobile_money"
+	PayoutBankAccount = "bank_account"
+)
+
+var (
+	ethiopianMobilePattern = regexp.MustCompile(`^(?:\+?251|0)([79]\d{8})$`)
+	bankAccountPattern     = regexp.MustCompile(`^\d{8,20}$`)
+	bankCodePattern        = regexp.MustCompile(`^[A-Z0-9]{2,16}$`)
+)
+
+// PayoutDestination is where a withdrawal is sent: a mobile money number or
+// a bank account with the code of its bank.
+type PayoutDestination struct {
+	MethodType string `json:"method_type"`
+	Account    string `json:"account"`
+	BankCode   string `json:"bank_code,omitempty"`
+}
+
+// Normalize validates a destination and returns it in canonical form:
+// mobile numbers as +2519XXXXXXXX or +2517XXXXXXXX, bank accounts as digits
+// with an upper-case bank code.
+func (d PayoutDestination) Normalize() (PayoutDestination, error) {
+	account := strings.NewReplacer(" ", "", "-", "").Replace(d.Account)
+	switch d.MethodType {
+	case PayoutMobileMoney:
+		m := ethiopianMobilePattern.FindStringSubmatch(account)
+		if m == nil {
+			return PayoutDestination{}, errors.New("mobile money account must be an Ethiopian mobile number")
+		}
+		return PayoutDestination{MethodType: d.MethodType, Account: "+251" + m[1]}, nil
+	case PayoutBankAccount:
+		if !bankAccountPattern.MatchString(account) {
+			return PayoutDestination{}, errors.New("bank account number must be 8 to 20 digits")
+		}
+		bankCode := strings.ToUpper(strings.TrimSpace(d.BankCode))
+		if !bankCodePattern.MatchString(bankCode) {
+			return PayoutDestination{}, errors.New("bank account needs a valid bank_code")
+		}
+		return PayoutDestination{MethodType: d.MethodType, Account: account, BankCode: bankCode}, nil
+	}
+	return PayoutDestination{}, fmt.Errorf("unsupported payout method %q", d.MethodType)
+}
+
+func (d PayoutDestination) String() string {
+	if d.BankCode != "" {
+		return fmt.Sprintf("%s %s/%s", d.MethodType, d.BankCode, d.Account)
+	}
+	return fmt.Sprintf("%s %s", d.MethodType, d.Account)
+}
+
+// Destination returns where payouts to this method are sent
+func (m *PayoutMethod) Destination() PayoutDestination {
+	return PayoutDestination{MethodType: m.MethodType, Account: m.Account, BankCode: m.BankCode}
+}
+
+// PayoutReference is the reference a withdrawal is paid out under. Payout
+// providers deduplicate on it, so resending a payout cannot pay it twice.
+func PayoutReference(withdrawalID int64) string {
+	return fmt.Sprintf("withdrawal-%d", withdrawalID)
+}
+
+// parsePayoutReference returns the withdrawal ID a payout reference is for
+func parsePayoutReference(reference string) (int64, error) {
+	var id int64
+	if _, err := fmt.Sscanf(reference, "withdrawal-%d", &id); err != nil {
+		return 0, fmt.Errorf("invalid payout reference %q", reference)
+	}
+	return id, nil
+}
+
+// Get the withdrawal paid out under a payout reference
+func (s *WithdrawalStore) GetWithdrawalByReference(ctx context.Context, reference string) (*WithdrawalRequest, error) {
+	id, err := parsePayoutReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWithdrawal(ctx, id)
+}
+
+// Get payout method by ID
+func (s *WithdrawalStore) GetPayoutMethod(ctx context.Context, id int64) (*PayoutMethod, error) {
+	var method PayoutMethod
+	err := s.DB.GetContext(ctx, &method, `SELECT * FROM payout_methods WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// List approved withdrawals whose payout is due to be sent
+func (s *WithdrawalStore) DuePayouts(ctx context.Context, limit int) ([]WithdrawalRequest, error) {
+	var reqs []WithdrawalRequest
+	err := s.DB.SelectContext(ctx, &reqs, `
+		SELECT * FROM withdrawal_requests
+		WHERE status = 'approved' AND payout_method_id IS NOT NULL
+			AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+		ORDER BY updated_at ASC
+		LIMIT $1
+	`, limit)
+	return reqs, err
+}
+
+// List payouts sent before cutoff that the provider has not reported on
+func (s *WithdrawalStore) StalePayouts(ctx context.Context, cutoff time.Time) ([]WithdrawalRequest, error) {
+	var reqs []WithdrawalRequest
+	err := s.DB.SelectContext(ctx, &reqs, `
+		SELECT * FROM withdrawal_requests
+		WHERE status = 'processing' AND updated_at < $1
+		ORDER BY updated_at ASC
+	`, cutoff)
+	return reqs, err
+}
+
+// StartPayout moves an approved withdrawal to processing before it is sent
+// through provider. Returns sql.ErrNoRows if the withdrawal is no longer
+// approved, e.g. because another dispatcher took it.
+func (s *WithdrawalStore) StartPayout(ctx context.Context, id int64, provider string) (*WithdrawalRequest, error) {
+	var req WithdrawalRequest
+	err := s.DB.GetContext(ctx, &req, `
+		UPDATE withdrawal_requests
+		SET status = 'processing', provider = $2, payout_attempts = payout_attempts + 1,
+			next_attempt_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'approved'
+		RETURNING *
+	`, id, provider)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Record the provider's ID for a payout it accepted
+func (s *WithdrawalStore) SetPayoutRef(ctx context.Context, id int64, providerRef string) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE withdrawal_requests SET provider_ref = $2, last_error = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, id, providerRef)
+	return err
+}
+
+// RetryPayout puts a payout the provider did not take back to approved, to be
+// sent again at retryAt. With a nil retryAt it gives up and fails the
+// withdrawal, returning the held funds to the wallet.
+func (s *WithdrawalStore) RetryPayout(ctx context.Context, id int64, cause string, retryAt *time.Time) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if retryAt == nil {
+		_, err = transitionWithdrawal(ctx, tx, id, WithdrawalFailed, nil, "payout failed: "+cause)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE withdrawal_requests
+			SET status = 'approved', next_attempt_at = $2, last_error = $3, updated_at = NOW()
+			WHERE id = $1 AND status = 'processing'
+		`, id, *retryAt, cause)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CompletePayout applies the outcome a payout provider reported for the
+// withdrawal paid out under reference: completed marks it paid, failed
+// returns the held funds. A withdrawal already paid or failed is returned
+// unchanged, so a redelivered callback has no effect, except that a payout
+// completed for a failed withdrawal is recorded as a discrepancy: the player
+// has been paid and had the funds returned. amount is what the provider
+// reports it paid, if it reports one; a payout of another amount is recorded
+// as a discrepancy and leaves the withdrawal processing for an operator.
+func (s *WithdrawalStore) CompletePayout(ctx context.Context, reference, providerRef string, paid bool, amount *Money) (*WithdrawalRequest, error) {
+	id, err := parsePayoutReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var req WithdrawalRequest
+	err = tx.GetContext(ctx, &req, `SELECT * FROM withdrawal_requests WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status == WithdrawalFailed && paid {
+		// The held funds are back in the wallet but the provider paid out too
+		log.Printf("[CompletePayout] Withdrawal %d was paid out by the provider after it failed; %s was returned to user %d",
+			req.ID, req.Amount, req.UserID)
+		if err := recordPayoutDiscrepancy(ctx, tx, &req, NewMoney(0, req.Amount.Cur())); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	}
+	if req.Status == WithdrawalPaid || req.Status == WithdrawalFailed {
+		return &req, nil
+	}
+	// A payout under dispute waits for an operator, whatever is reported next
+	var disputed bool
+	err = tx.GetContext(ctx, &disputed, `
+		SELECT EXISTS (SELECT 1 FROM reconciliation_discrepancies WHERE kind = $1 AND withdrawal_id = $2 AND resolved_at IS NULL)
+	`, DiscrepancyPayout, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if disputed {
+		return &req, nil
+	}
+	if paid && amount != nil && amount.Cmp(req.Amount) != 0 {
+		log.Printf("[CompletePayout] Withdrawal %d of %s was paid out as %s by the provider", req.ID, req.Amount, *amount)
+		if err := recordPayoutDiscrepancy(ctx, tx, &req, *amount); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	}
+
+	status, note := WithdrawalPaid, ""
+	if !paid {
+		status, note = WithdrawalFailed, "payout failed at the provider"
+	}
+	updated, err := transitionWithdrawal(ctx, tx, id, status, nil, note)
+	if err != nil {
+		return nil, err
+	}
+	if providerRef != "" {
+		err = tx.GetContext(ctx, updated, `
+			UPDATE withdrawal_requests SET provider_ref = $2 WHERE id = $1 RETURNING *
+		`, id, providerRef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// recordPayoutDiscrepancy records a payout the provider made that does not
+// match the withdrawal. expected is the withdrawal amount and actual what the
+// payout accounts for: nothing once the funds went back to the wallet,
+// otherwise the amount paid. A redelivered callback keeps the one open record.
+func recordPayoutDiscrepancy(ctx context.Context, tx *sqlx.Tx, req *WithdrawalRequest, actual Money) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO reconciliation_discrepancies (kind, user_id, withdrawal_id, expected, actual)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, COALESCE(user_id, 0), COALESCE(room_id, 0), COALESCE(withdrawal_id, 0)) WHERE resolved_at IS NULL
+		DO UPDATE SET last_seen_at = NOW()
+	`, DiscrepancyPayout, req.UserID, req.ID, req.Amount, actual)
+	return err
+}
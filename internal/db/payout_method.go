@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var (
+	// ErrPayoutMethodNotFound is returned for a payout method the user does not have
+	ErrPayoutMethodNotFound = errors.New("payout method not found")
+	// ErrPayoutMethodUnverified is returned when withdrawing to a method that is not verified yet
+	ErrPayoutMethodUnverified = errors.New("payout method has not been verified")
+)
+
+// AddPayoutMethod saves a normalized destination as an unverified payout
+// method of the user. Adding a destination the user already has returns it;
+// if it had been removed it is restored and must be verified again.
+func (s *WithdrawalStore) AddPayoutMethod(ctx context.Context, userID int64, destination PayoutDestination, label string) (*PayoutMethod, error) {
+	var labelPtr *string
+	if label != "" {
+		labelPtr = &label
+	}
+	var method PayoutMethod
+	err := s.DB.GetContext(ctx, &method, `
+		INSERT INTO payout_methods (user_id, method_type, account, bank_code, label)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, method_type, account, bank_code) DO UPDATE
+		SET label = COALESCE(EXCLUDED.label, payout_methods.label),
+			verified_at = CASE WHEN payout_methods.removed_at IS NULL THEN payout_methods.verified_at END,
+			verified_by = CASE WHEN payout_methods.removed_at IS NULL THEN payout_methods.verified_by END,
+			removed_at = NULL
+		RETURNING *
+	`, userID, destination.MethodType, destination.Account, destination.BankCode, labelPtr)
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// Get payout methods by user ID
+func (s *WithdrawalStore) ListPayoutMethods(ctx context.Context, userID int64) ([]PayoutMethod, error) {
+	var methods []PayoutMethod
+	err := s.DB.SelectContext(ctx, &methods, `
+		SELECT * FROM payout_methods WHERE user_id = $1 AND removed_at IS NULL ORDER BY created_at ASC
+	`, userID)
+	return methods, err
+}
+
+// Remove a payout method of the user. It is kept for the withdrawals that
+// were sent to it.
+func (s *WithdrawalStore) RemovePayoutMethod(ctx context.Context, userID, id int64) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE payout_methods SET removed_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND removed_at IS NULL
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPayoutMethodNotFound
+	}
+	return nil
+}
+
+// List payout methods waiting for verification, oldest first
+func (s *WithdrawalStore) ListUnverifiedPayoutMethods(ctx context.Context) ([]PayoutMethod, error) {
+	var methods []PayoutMethod
+	err := s.DB.SelectContext(ctx, &methods, `
+		SELECT * FROM payout_methods WHERE verified_at IS NULL AND removed_at IS NULL ORDER BY created_at ASC
+	`)
+	return methods, err
+}
+
+// VerifyPayoutMethod records that an operator checked the user owns a payout method
+func (s *WithdrawalStore) VerifyPayoutMethod(ctx context.Context, id, operatorID int64) (*PayoutMethod, error) {
+	var method PayoutMethod
+	err := s.DB.GetContext(ctx, &method, `
+		UPDATE payout_methods SET verified_at = NOW(), verified_by = $2
+		WHERE id = $1 AND removed_at IS NULL
+		RETURNING *
+	`, id, operatorID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPayoutMethodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
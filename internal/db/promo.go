@@ -0,0 +1,359 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Promo code kinds
+const (
+	PromoFixed        = "fixed"
+	PromoDepositMatch = "deposit_match"
+)
+
+// Promo redemption statuses
+const (
+	RedemptionPendingDeposit = "pending_deposit"
+	RedemptionActive         = "active"
+	RedemptionCompleted      = "completed"
+	RedemptionForfeited      = "forfeited"
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+var (
+	// ErrPromoNotFound is returned for an unknown or deactivated promo code
+	ErrPromoNotFound = errors.New("promo code not found")
+	// ErrPromoExpired is returned for a promo code past its expiry
+	ErrPromoExpired = errors.New("promo code has expired")
+	// ErrPromoExhausted is returned for a promo code redeemed the maximum number of times
+	ErrPromoExhausted = errors.New("promo code has been fully redeemed")
+	// ErrPromoAlreadyRedeemed is returned when a user redeems the same code twice
+	ErrPromoAlreadyRedeemed = errors.New("promo code already redeemed")
+	// ErrBonusActive is returned when a user redeems a code before finishing their current bonus
+	ErrBonusActive = errors.New("finish wagering your current bonus first")
+	// ErrDuplicatePromoCode is returned when creating a code that already exists
+	ErrDuplicatePromoCode = errors.New("promo code already exists")
+)
+
+// NormalizePromoCode returns a code as it is stored: trimmed and upper-case
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks the settings of a new promo code
+func (p *PromoCode) Validate() error {
+	if !promoCodePattern.MatchString(p.Code) {
+		return errors.New("code must be 3 to 32 letters, digits, '-' or '_'")
+	}
+	switch p.Kind {
+	case PromoFixed:
+		if !p.Amount.IsPositive() {
+			return errors.New("a fixed promo needs a positive amount")
+		}
+	case PromoDepositMatch:
+		if p.MatchBps <= 0 || p.MatchBps > 100000 {
+			return errors.New("match_bps must be between 1 and 100000")
+		}
+	default:
+		return fmt.Errorf("unsupported promo kind %q", p.Kind)
+	}
+	if p.MaxBonus != nil && !p.MaxBonus.IsPositive() {
+		return errors.New("max_bonus must be positive")
+	}
+	if p.WageringMultiplier < 0 || p.WageringMultiplier > 100 {
+		return errors.New("wagering_multiplier must be between 0 and 100")
+	}
+	if p.MaxRedemptions != nil && *p.MaxRedemptions <= 0 {
+		return errors.New("max_redemptions must be positive")
+	}
+	return nil
+}
+
+// UserRedemption is a redemption with the code it was made with
+type UserRedemption struct {
+	PromoRedemption
+	Code string `db:"code" json:"code"`
+}
+
+type PromoStore struct {
+	DB *sqlx.DB
+}
+
+func NewPromoStore(db *sqlx.DB) *PromoStore {
+	return &PromoStore{DB: db}
+}
+
+// Create a promo code on behalf of an operator
+func (s *PromoStore) CreatePromoCode(ctx context.Context, p PromoCode, createdBy int64) (*PromoCode, error) {
+	var promo PromoCode
+	err := s.DB.GetContext(ctx, &promo, `
+		INSERT INTO promo_codes (code, kind, amount, match_bps, max_bonus, wagering_multiplier,
+			expires_at, max_redemptions, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING *
+	`, p.Code, p.Kind, p.Amount, p.MatchBps, p.MaxBonus, p.WageringMultiplier, p.ExpiresAt, p.MaxRedemptions, createdBy)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicatePromoCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// List all promo codes, newest first
+func (s *PromoStore) ListPromoCodes(ctx context.Context) ([]PromoCode, error) {
+	var promos []PromoCode
+	err := s.DB.SelectContext(ctx, &promos, `SELECT * FROM promo_codes ORDER BY created_at DESC`)
+	return promos, err
+}
+
+// Deactivate a promo code so it can no longer be redeemed. Bonuses already
+// credited from it are kept.
+func (s *PromoStore) DeactivatePromoCode(ctx context.Context, id int64) (*PromoCode, error) {
+	var promo PromoCode
+	err := s.DB.GetContext(ctx, &promo, `
+		UPDATE promo_codes SET active = FALSE WHERE id = $1 RETURNING *
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// List a user's promo redemptions, newest first
+func (s *PromoStore) ListUserRedemptions(ctx context.Context, userID int64) ([]UserRedemption, error) {
+	var reds []UserRedemption
+	err := s.DB.SelectContext(ctx, &reds, `
+		SELECT r.*, p.code FROM promo_redemptions r
+		JOIN promo_codes p ON p.id = r.promo_code_id
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC
+	`, userID)
+	return reds, err
+}
+
+// Redeem applies a promo code for a user. A fixed code credits its bonus at
+// once; a deposit match waits for the user's next completed deposit. A user
+// has one bonus at a time: a bonus still being wagered returns
+// ErrBonusActive, unless all of it has been lost, in which case it is
+// forfeited and the new code applies.
+func (s *PromoStore) Redeem(ctx context.Context, userID int64, code string) (*PromoRedemption, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var promo PromoCode
+	err = tx.GetContext(ctx, &promo, `SELECT * FROM promo_codes WHERE code = $1 FOR UPDATE`, NormalizePromoCode(code))
+	if err == sql.ErrNoRows {
+		return nil, ErrPromoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !promo.Active {
+		return nil, ErrPromoNotFound
+	}
+	if promo.ExpiresAt != nil && !time.Now().Before(*promo.ExpiresAt) {
+		return nil, ErrPromoExpired
+	}
+	if promo.MaxRedemptions != nil && promo.Redemptions >= *promo.MaxRedemptions {
+		return nil, ErrPromoExhausted
+	}
+
+	var redeemed bool
+	err = tx.GetContext(ctx, &redeemed, `
+		SELECT EXISTS (SELECT 1 FROM promo_redemptions WHERE promo_code_id = $1 AND user_id = $2)
+	`, promo.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if redeemed {
+		return nil, ErrPromoAlreadyRedeemed
+	}
+	if err := forfeitLostBonus(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	status := RedemptionActive
+	if promo.Kind == PromoDepositMatch {
+		status = RedemptionPendingDeposit
+	}
+	var red PromoRedemption
+	err = tx.GetContext(ctx, &red, `
+		INSERT INTO promo_redemptions (promo_code_id, user_id, status) VALUES ($1, $2, $3)
+		RETURNING *
+	`, promo.ID, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE promo_codes SET redemptions = redemptions + 1 WHERE id = $1`, promo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if promo.Kind == PromoFixed {
+		if err := creditBonus(ctx, tx, &red, &promo, promo.Amount, Source{}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &red, nil
+}
+
+// forfeitLostBonus closes the user's open bonus if nothing is left of it, so
+// a new one can be redeemed. Returns ErrBonusActive if it is still in play.
+func forfeitLostBonus(ctx context.Context, tx *sqlx.Tx, userID int64) error {
+	var open PromoRedemption
+	err := tx.GetContext(ctx, &open, `
+		SELECT * FROM promo_redemptions
+		WHERE user_id = $1 AND status IN ('pending_deposit', 'active')
+		FOR UPDATE
+	`, userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if open.Status != RedemptionActive {
+		return ErrBonusActive
+	}
+	var inPlay bool
+	err = tx.GetContext(ctx, &inPlay, `
+		SELECT w.bonus_balance > 0 OR EXISTS (
+			SELECT 1 FROM user_bets b WHERE b.user_id = w.user_id AND b.status = 'held' AND b.bonus_amount > 0
+		)
+		FROM wallets w WHERE w.user_id = $1
+	`, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if inPlay {
+		return ErrBonusActive
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE promo_redemptions SET status = 'forfeited', completed_at = NOW() WHERE id = $1
+	`, open.ID)
+	return err
+}
+
+// creditBonus pays a redemption's bonus from the promotions account into the
+// user's bonus account and starts its wagering requirement: the bonus times
+// the code's wagering multiplier. A bonus with nothing to wager is released
+// to cash straight away.
+func creditBonus(ctx context.Context, tx *sqlx.Tx, red *PromoRedemption, promo *PromoCode, amount Money, src Source) error {
+	if promo.MaxBonus != nil && amount.Cmp(*promo.MaxBonus) > 0 {
+		amount = *promo.MaxBonus
+	}
+	if amount.IsPositive() {
+		_, err := postJournal(ctx, tx, Transfer(JournalBonus, "promo "+promo.Code,
+			PromotionsAccount(), UserBonusAccount(red.UserID), amount).WithSource(src))
+		if err != nil {
+			return err
+		}
+	}
+	err := tx.GetContext(ctx, red, `
+		UPDATE promo_redemptions
+		SET status = 'active', bonus_amount = $2, wagering_required = $3,
+			deposit_id = NULLIF($4::integer, 0), activated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, red.ID, amount, amount.Mul(int64(promo.WageringMultiplier)), src.DepositID)
+	if err != nil {
+		return err
+	}
+	if red.Wagered.Cmp(red.WageringRequired) >= 0 {
+		return releaseBonus(ctx, tx, red)
+	}
+	return nil
+}
+
+// applyDepositMatch credits the deposit match the user redeemed, if any,
+// for a deposit that has just completed
+func applyDepositMatch(ctx context.Context, tx *sqlx.Tx, dep *PaymentDeposit) error {
+	var red PromoRedemption
+	err := tx.GetContext(ctx, &red, `
+		SELECT * FROM promo_redemptions WHERE user_id = $1 AND status = 'pending_deposit' FOR UPDATE
+	`, dep.UserID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var promo PromoCode
+	if err := tx.GetContext(ctx, &promo, `SELECT * FROM promo_codes WHERE id = $1`, red.PromoCodeID); err != nil {
+		return err
+	}
+	return creditBonus(ctx, tx, &red, &promo, dep.Amount.MulBps(int64(promo.MatchBps)), Source{DepositID: dep.ID})
+}
+
+// applyWagering counts settled stakes towards their players' wagering
+// requirements and releases every bonus whose requirement is met
+func applyWagering(ctx context.Context, tx *sqlx.Tx, bets []UserBet) error {
+	for _, bet := range bets {
+		if !bet.BetAmount.IsPositive() {
+			continue
+		}
+		var red PromoRedemption
+		err := tx.GetContext(ctx, &red, `
+			UPDATE promo_redemptions SET wagered = wagered + $2
+			WHERE user_id = $1 AND status = 'active'
+			RETURNING *
+		`, bet.UserID, bet.BetAmount)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if red.Wagered.Cmp(red.WageringRequired) >= 0 {
+			if err := releaseBonus(ctx, tx, &red); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// releaseBonus completes a redemption, moving what is left of the user's
+// bonus into their cash wallet
+func releaseBonus(ctx context.Context, tx *sqlx.Tx, red *PromoRedemption) error {
+	var bonus Money
+	err := tx.GetContext(ctx, &bonus, `SELECT bonus_balance FROM wallets WHERE user_id = $1 FOR UPDATE`, red.UserID)
+	if err != nil {
+		return err
+	}
+	if bonus.IsPositive() {
+		_, err = postJournal(ctx, tx, Transfer(JournalBonusRelease, fmt.Sprintf("promo redemption %d", red.ID),
+			UserBonusAccount(red.UserID), UserWalletAccount(red.UserID), bonus))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.GetContext(ctx, red, `
+		UPDATE promo_redemptions SET status = 'completed', completed_at = NOW() WHERE id = $1 RETURNING *
+	`, red.ID)
+}
+
+// hasActiveBonus reports whether the user has a bonus still being wagered
+func hasActiveBonus(ctx context.Context, tx *sqlx.Tx, userID int64) (bool, error) {
+	var active bool
+	err := tx.GetContext(ctx, &active, `
+		SELECT EXISTS (SELECT 1 FROM promo_redemptions WHERE user_id = $1 AND status = 'active')
+	`, userID)
+	return active, err
+}
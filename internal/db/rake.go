@@ -0,0 +1,76 @@
+package db
+
+import "errors"
+
+// RakeConfig is the house commission taken from a pot at payout: RakeBps
+// basis points of the pot, at least RakeMin and at most RakeCap when set.
+// Rooms copy it from their tier when they are created.
+type RakeConfig struct {
+	RakeBps int    `db:"rake_bps" json:"rake_bps"`
+	RakeMin Money  `db:"rake_min" json:"rake_min"`
+	RakeCap *Money `db:"rake_cap" json:"rake_cap"`
+}
+
+// Validate checks the commission settings
+func (r RakeConfig) Validate() error {
+	if r.RakeBps < 0 || r.RakeBps > 10000 {
+		return errors.New("rake_bps must be between 0 and 10000")
+	}
+	if r.RakeMin.IsNegative() {
+		return errors.New("rake_min must not be negative")
+	}
+	if r.RakeCap != nil {
+		if r.RakeCap.IsNegative() {
+			return errors.New("rake_cap must not be negative")
+		}
+		if r.RakeCap.Cmp(r.RakeMin) < 0 {
+			return errors.New("rake_cap must not be below rake_min")
+		}
+	}
+	return nil
+}
+
+// Rake returns the commission on pot, rounded down to a whole minor unit and
+// never more than the pot itself
+func (r RakeConfig) Rake(pot Money) Money {
+	rake := pot.MulBps(int64(r.RakeBps))
+	if rake.Cmp(r.RakeMin) < 0 {
+		rake = NewMoney(r.RakeMin.Minor, pot.Cur())
+	}
+	if r.RakeCap != nil && rake.Cmp(*r.RakeCap) > 0 {
+		rake = NewMoney(r.RakeCap.Minor, pot.Cur())
+	}
+	if rake.Cmp(pot) > 0 {
+		rake = pot
+	}
+	if rake.IsNegative() {
+		rake = NewMoney(0, pot.Cur())
+	}
+	return rake
+}
+
+// StageRake returns the commission on one prize stage of a pot, prize being
+// what the stage pays out and booked the rake earlier stages already took.
+// The commission is on the pot as a whole: each stage pays RakeBps of its
+// prize, never taking the total past RakeCap, and the final stage brings the
+// total to the rake on the whole pot, so RakeMin is charged once.
+func (r RakeConfig) StageRake(pot, prize, booked Money, final bool) Money {
+	var rake Money
+	if final {
+		rake = r.Rake(pot).Sub(booked)
+	} else {
+		rake = prize.MulBps(int64(r.RakeBps))
+		if r.RakeCap != nil {
+			if left := NewMoney(r.RakeCap.Minor, pot.Cur()).Sub(booked); rake.Cmp(left) > 0 {
+				rake = left
+			}
+		}
+	}
+	if rake.Cmp(prize) > 0 {
+		rake = prize
+	}
+	if rake.IsNegative() {
+		rake = NewMoney(0, pot.Cur())
+	}
+	return rake
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Discrepancy kinds
+const (
+	DiscrepancyWallet = "wallet"
+	DiscrepancyBonus  = "bonus"
+	DiscrepancyRoom   = "room"
+	DiscrepancyPayout = "payout"
+)
+
+// ErrDiscrepancyNotFound is returned when resolving a discrepancy that is not open
+var ErrDiscrepancyNotFound = errors.New("open discrepancy not found")
+
+// FindDiscrepancies compares the balances the ledger should hold with what
+// it holds. A wallet is expected to cache the ledger balances of its cash
+// and bonus accounts; a room escrow
+// is expected to hold exactly its held stakes plus what its active session's
+// pot (the pot carried into it and the stakes settled at earlier prize
+// stages) has not paid out yet, so anything left over or missing after a
+// payout shows up.
+func (s *LedgerStore) FindDiscrepancies(ctx context.Context) ([]Discrepancy, error) {
+	var found []Discrepancy
+	err := s.DB.SelectContext(ctx, &found, `
+		SELECT 'wallet' AS kind, w.user_id, NULL::integer AS room_id,
+			COALESCE(l.balance, 0) AS expected, w.balance AS actual
+		FROM wallets w
+		LEFT JOIN (
+			SELECT a.user_id, SUM(e.amount) AS balance
+			FROM ledger_entries e JOIN ledger_accounts a ON a.id = e.account_id
+			WHERE a.kind = 'user_wallet'
+			GROUP BY a.user_id
+		) l ON l.user_id = w.user_id
+		WHERE w.balance <> COALESCE(l.balance, 0)
+		ORDER BY w.user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var bonuses []Discrepancy
+	err = s.DB.SelectContext(ctx, &bonuses, `
+		SELECT 'bonus' AS kind, w.user_id, NULL::integer AS room_id,
+			COALESCE(l.balance, 0) AS expected, w.bonus_balance AS actual
+		FROM wallets w
+		LEFT JOIN (
+			SELECT a.user_id, SUM(e.amount) AS balance
+			FROM ledger_entries e JOIN ledger_accounts a ON a.id = e.account_id
+			WHERE a.kind = 'user_bonus'
+			GROUP BY a.user_id
+		) l ON l.user_id = w.user_id
+		WHERE w.bonus_balance <> COALESCE(l.balance, 0)
+		ORDER BY w.user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, bonuses...)
+
+	var rooms []Discrepancy
+	err = s.DB.SelectContext(ctx, &rooms, `
+		SELECT 'room' AS kind, NULL::integer AS user_id, r.id AS room_id,
+			COALESCE(held.total, 0) + COALESCE(active.unpaid, 0) AS expected,
+			COALESCE(esc.balance, 0) AS actual
+		FROM bingo_rooms r
+		LEFT JOIN (
+			SELECT a.room_id, SUM(e.amount) AS balance
+			FROM ledger_entries e JOIN ledger_accounts a ON a.id = e.account_id
+			WHERE a.kind = 'room_escrow'
+			GROUP BY a.room_id
+		) esc ON esc.room_id = r.id
+		LEFT JOIN (
+			SELECT room_id, SUM(bet_amount) AS total FROM user_bets WHERE status = 'held' GROUP BY room_id
+		) held ON held.room_id = r.id
+		LEFT JOIN (
+			SELECT s.room_id, SUM(s.pot - COALESCE(w.paid, 0)) AS unpaid
+			FROM game_sessions s
+			LEFT JOIN (
+				SELECT session_id, SUM(winnings + rake) AS paid FROM winners GROUP BY session_id
+			) w ON w.session_id = s.id
+			WHERE s.status = 'active'
+			GROUP BY s.room_id
+		) active ON active.room_id = r.id
+		WHERE COALESCE(esc.balance, 0) <> COALESCE(held.total, 0) + COALESCE(active.unpaid, 0)
+		ORDER BY r.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return append(found, rooms...), nil
+}
+
+// RecordDiscrepancies stores what FindDiscrepancies found and returns the
+// discrepancies that were not already open. An open discrepancy that is
+// found again has its amounts refreshed. With freeze, the wallets of new
+// wallet and bonus discrepancies are frozen until an operator resolves them.
+func (s *LedgerStore) RecordDiscrepancies(ctx context.Context, found []Discrepancy, freeze bool) ([]Discrepancy, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var opened []Discrepancy
+	for _, d := range found {
+		var rec struct {
+			Discrepancy
+			IsNew bool `db:"is_new"`
+		}
+		err := tx.GetContext(ctx, &rec, `
+			INSERT INTO reconciliation_discrepancies (kind, user_id, room_id, expected, actual)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (kind, COALESCE(user_id, 0), COALESCE(room_id, 0), COALESCE(withdrawal_id, 0)) WHERE resolved_at IS NULL
+			DO UPDATE SET expected = EXCLUDED.expected, actual = EXCLUDED.actual, last_seen_at = NOW()
+			RETURNING *, (xmax = 0) AS is_new
+		`, d.Kind, d.UserID, d.RoomID, d.Expected, d.Actual)
+		if err != nil {
+			return nil, err
+		}
+		if !rec.IsNew {
+			continue
+		}
+		opened = append(opened, rec.Discrepancy)
+		if freeze && rec.UserID != nil {
+			// Leave a wallet an operator already froze as it is
+			_, err = tx.ExecContext(ctx, `
+				UPDATE wallets SET frozen_at = NOW(), frozen_reason = $2, updated_at = NOW()
+				WHERE user_id = $1 AND frozen_at IS NULL
+			`, *rec.UserID, fmt.Sprintf("reconciliation discrepancy %d", rec.ID))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return opened, nil
+}
+
+// List discrepancies, newest first: open ones, resolved ones or all
+func (s *LedgerStore) ListDiscrepancies(ctx context.Context, status string) ([]Discrepancy, error) {
+	var list []Discrepancy
+	err := s.DB.SelectContext(ctx, &list, `
+		SELECT * FROM reconciliation_discrepancies
+		WHERE $1 = 'all' OR ($1 = 'resolved') = (resolved_at IS NOT NULL)
+		ORDER BY detected_at DESC
+	`, status)
+	return list, err
+}
+
+// ResolveDiscrepancy closes an open discrepancy. The wallet it froze is
+// unfrozen once none of the user's discrepancies is left open.
+func (s *LedgerStore) ResolveDiscrepancy(ctx context.Context, id, operatorID int64, note string) (*Discrepancy, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var d Discrepancy
+	err = tx.GetContext(ctx, &d, `
+		UPDATE reconciliation_discrepancies
+		SET resolved_at = NOW(), resolved_by = $2, resolution_note = $3
+		WHERE id = $1 AND resolved_at IS NULL
+		RETURNING *
+	`, id, operatorID, note)
+	if err == sql.ErrNoRows {
+		return nil, ErrDiscrepancyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.UserID != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE wallets SET frozen_at = NULL, frozen_reason = NULL, updated_at = NOW()
+			WHERE user_id = $1 AND frozen_reason LIKE 'reconciliation discrepancy %'
+				AND NOT EXISTS (
+					SELECT 1 FROM reconciliation_discrepancies
+					WHERE user_id = $1 AND resolved_at IS NULL
+				)
+		`, *d.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Freeze a user's wallet so no money can be taken out of it
+func (s *LedgerStore) FreezeWallet(ctx context.Context, userID int64, reason string) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE wallets SET frozen_at = COALESCE(frozen_at, NOW()), frozen_reason = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`, userID, reason)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Unfreeze a user's wallet
+func (s *LedgerStore) UnfreezeWallet(ctx context.Context, userID int64) error {
+	res, err := s.DB.ExecContext(ctx, `
+		UPDATE wallets SET frozen_at = NULL, frozen_reason = NULL, updated_at = NOW() WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Global config for referral rewards. A referee qualifies once they have
+// deposited MinDeposit and staked MinWagered in settled games; the referrer
+// and the referee are then paid their rewards once.
+type ReferralConfig struct {
+	ReferrerReward Money
+	RefereeReward  Money
+	MinDeposit     Money
+	MinWagered     Money
+}
+
+var referralConfig = ReferralConfig{
+	ReferrerReward: NewMoney(1000, DefaultCurrency),
+	RefereeReward:  NewMoney(0, DefaultCurrency),
+	MinDeposit:     NewMoney(5000, DefaultCurrency),
+	MinWagered:     NewMoney(2000, DefaultCurrency),
+} // default
+
+// Set referral config from env
+func SetReferralConfigFromEnv() {
+	if val, ok := lookupEnvMoney("REFERRAL_REWARD"); ok {
+		referralConfig.ReferrerReward = val
+	}
+	if val, ok := lookupEnvMoney("REFERRAL_REFEREE_REWARD"); ok {
+		referralConfig.RefereeReward = val
+	}
+	if val, ok := lookupEnvMoney("REFERRAL_MIN_DEPOSIT"); ok {
+		referralConfig.MinDeposit = val
+	}
+	if val, ok := lookupEnvMoney("REFERRAL_MIN_WAGERED"); ok {
+		referralConfig.MinWagered = val
+	}
+}
+
+// GetReferralConfig returns the referral rewards and qualifying thresholds in effect
+func GetReferralConfig() ReferralConfig {
+	return referralConfig
+}
+
+// Helper to parse a non-negative amount env var
+func lookupEnvMoney(key string) (Money, bool) {
+	val := os.Getenv(key)
+	if val == "" {
+		return Money{}, false
+	}
+	m, err := ParseMoney(val, DefaultCurrency)
+	if err != nil || m.IsNegative() {
+		return Money{}, false
+	}
+	return m, true
+}
+
+var (
+	// ErrReferrerNotFound is returned when the referral link names an unknown user
+	ErrReferrerNotFound = errors.New("referrer not found")
+	// ErrSelfReferral is returned when a user follows their own referral link
+	ErrSelfReferral = errors.New("users cannot refer themselves")
+	// ErrReferralNotEligible is returned for a user already referred or already playing
+	ErrReferralNotEligible = errors.New("only new players can be referred")
+)
+
+// ReferredUser is a referral with the name of the referee
+type ReferredUser struct {
+	Referral
+	Username  string `db:"username"   json:"username"`
+	FirstName string `db:"first_name" json:"first_name"`
+}
+
+type ReferralStore struct {
+	DB *sqlx.DB
+}
+
+func NewReferralStore(db *sqlx.DB) *ReferralStore {
+	return &ReferralStore{DB: db}
+}
+
+// RecordReferral records that the user with referrerTelegramID invited
+// refereeID. Only users who have not been referred, deposited or staked yet
+// can be referred; anyone else returns ErrReferralNotEligible.
+func (s *ReferralStore) RecordReferral(ctx context.Context, refereeID, referrerTelegramID int64) (*Referral, error) {
+	var referrerID int64
+	err := s.DB.GetContext(ctx, &referrerID, `SELECT id FROM users WHERE telegram_id = $1`, referrerTelegramID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReferrerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if referrerID == refereeID {
+		return nil, ErrSelfReferral
+	}
+
+	var ref Referral
+	err = s.DB.GetContext(ctx, &ref, `
+		INSERT INTO referrals (referrer_id, referee_id)
+		SELECT $1, $2
+		WHERE NOT EXISTS (SELECT 1 FROM payment_deposits WHERE user_id = $2 AND status = 'completed')
+			AND NOT EXISTS (SELECT 1 FROM user_bets WHERE user_id = $2)
+		ON CONFLICT (referee_id) DO NOTHING
+		RETURNING *
+	`, referrerID, refereeID)
+	if err == sql.ErrNoRows {
+		return nil, ErrReferralNotEligible
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// List the users a referrer invited, newest first
+func (s *ReferralStore) ListReferrals(ctx context.Context, referrerID int64) ([]ReferredUser, error) {
+	var refs []ReferredUser
+	err := s.DB.SelectContext(ctx, &refs, `
+		SELECT r.*, COALESCE(u.username, '') AS username, COALESCE(u.first_name, '') AS first_name
+		FROM referrals r JOIN users u ON u.id = r.referee_id
+		WHERE r.referrer_id = $1
+		ORDER BY r.created_at DESC
+	`, referrerID)
+	return refs, err
+}
+
+// attributeDeposit adds a completed deposit to the depositor's referral
+func attributeDeposit(ctx context.Context, tx *sqlx.Tx, userID int64, amount Money) error {
+	return attributeReferral(ctx, tx, userID, amount, NewMoney(0, amount.Cur()))
+}
+
+// attributeWagers adds settled stakes to their players' referrals
+func attributeWagers(ctx context.Context, tx *sqlx.Tx, bets []UserBet) error {
+	for _, bet := range bets {
+		if !bet.BetAmount.IsPositive() {
+			continue
+		}
+		if err := attributeReferral(ctx, tx, bet.UserID, NewMoney(0, bet.BetAmount.Cur()), bet.BetAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attributeReferral adds to what a referee has deposited and wagered, and
+// pays the referral rewards when that makes the referee qualify
+func attributeReferral(ctx context.Context, tx *sqlx.Tx, refereeID int64, deposited, wagered Money) error {
+	var ref Referral
+	err := tx.GetContext(ctx, &ref, `
+		UPDATE referrals SET deposited = deposited + $2, wagered = wagered + $3
+		WHERE referee_id = $1
+		RETURNING *
+	`, refereeID, deposited, wagered)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cfg := referralConfig
+	if ref.QualifiedAt != nil || ref.Deposited.Cmp(cfg.MinDeposit) < 0 || ref.Wagered.Cmp(cfg.MinWagered) < 0 {
+		return nil
+	}
+
+	total := cfg.ReferrerReward.Add(cfg.RefereeReward)
+	if total.IsPositive() {
+		_, err = postJournal(ctx, tx, Journal{
+			Kind: JournalReferral,
+			Memo: fmt.Sprintf("referral %d", ref.ID),
+			Postings: []Posting{
+				{Account: PromotionsAccount(), Amount: total.Neg()},
+				{Account: UserWalletAccount(ref.ReferrerID), Amount: cfg.ReferrerReward},
+				{Account: UserWalletAccount(ref.RefereeID), Amount: cfg.RefereeReward},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE referrals SET qualified_at = NOW(), referrer_reward = $2, referee_reward = $3 WHERE id = $1
+	`, ref.ID, cfg.ReferrerReward, cfg.RefereeReward)
+	return err
+}
@@ -2,15 +2,21 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"rockbingo/internal/game"
 	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrRoomNotWaiting is returned for changes only allowed before a room starts
+var ErrRoomNotWaiting = errors.New("room does not exist or has already started")
+
 type RoomStore struct {
 	DB *sqlx.DB
 }
@@ -19,17 +25,23 @@ func NewRoomStore(db *sqlx.DB) *RoomStore {
 	return &RoomStore{DB: db}
 }
 
-// Create a new room
-func (s *RoomStore) CreateRoom(ctx context.Context, betAmount float64, maxPlayers int) (*BingoRoom, error) {
+// Create a new room playing the given variant, won with the variant's
+// default pattern
+func (s *RoomStore) CreateRoom(ctx context.Context, betAmount Money, maxPlayers int, variant game.Variant) (*BingoRoom, error) {
 	if maxPlayers <= 0 {
 		maxPlayers = 100
 	}
 	var room BingoRoom
+	// Rooms take the settings of the tier for their bet amount, if any
 	err := s.DB.GetContext(ctx, &room, `
-		INSERT INTO bingo_rooms (bet_amount, max_players, current_players, status, countdown_start, game_start_time)
-		VALUES ($1, $2, 0, 'waiting', NULL, NULL)
+		INSERT INTO bingo_rooms (bet_amount, max_players, current_players, status, tier_id, no_winner_policy,
+			rake_bps, rake_min, rake_cap, draw_interval_seconds, variant, win_pattern)
+		SELECT $1::numeric, $2::integer, 0, 'waiting', t.id, COALESCE(t.no_winner_policy, 'refund'),
+			COALESCE(t.rake_bps, 0), COALESCE(t.rake_min, 0), t.rake_cap, $3, $4, $5
+		FROM (SELECT 1) AS one
+		LEFT JOIN room_tiers t ON t.bet_amount = $1
 		RETURNING *
-	`, betAmount, maxPlayers)
+	`, betAmount, maxPlayers, int(roomConfig.DrawInterval/time.Second), variant.Name(), variant.DefaultPattern())
 	if err != nil {
 		return nil, err
 	}
@@ -53,18 +65,59 @@ func (s *RoomStore) GetRoom(ctx context.Context, id int64) (*BingoRoom, error) {
 	return &room, nil
 }
 
-// Global config for min players to start
+// Override the house commission of a room that has not started yet
+func (s *RoomStore) SetRoomRake(ctx context.Context, roomID int64, rake RakeConfig) (*BingoRoom, error) {
+	var room BingoRoom
+	err := s.DB.GetContext(ctx, &room, `
+		UPDATE bingo_rooms SET rake_bps = $2, rake_min = $3, rake_cap = $4, updated_at = NOW()
+		WHERE id = $1 AND status = 'waiting'
+		RETURNING *
+	`, roomID, rake.RakeBps, rake.RakeMin, rake.RakeCap)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomNotWaiting
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// Set how often numbers are drawn in a room. A game in progress keeps the
+// draw already scheduled and uses the new interval after it.
+func (s *RoomStore) SetDrawInterval(ctx context.Context, roomID int64, seconds int) (*BingoRoom, error) {
+	var room BingoRoom
+	err := s.DB.GetContext(ctx, &room, `
+		UPDATE bingo_rooms SET draw_interval_seconds = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, roomID, seconds)
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// Global config for min players to start, the bingo claim window and the
+// draw interval of new rooms
 type RoomConfig struct {
 	MinPlayersToStart int
+	ClaimWindow       time.Duration
+	DrawInterval      time.Duration
 }
 
-var roomConfig = RoomConfig{MinPlayersToStart: 1} // default
+var roomConfig = RoomConfig{MinPlayersToStart: 1, ClaimWindow: 5 * time.Second, DrawInterval: 5 * time.Second} // default
 
 // Set config from env
 func SetRoomConfigFromEnv() {
 	if val, ok := lookupEnvInt("MIN_PLAYERS_TO_START"); ok {
 		roomConfig.MinPlayersToStart = val
 	}
+	if val, ok := lookupEnvInt("CLAIM_WINDOW_SECONDS"); ok && val >= 0 {
+		roomConfig.ClaimWindow = time.Duration(val) * time.Second
+	}
+	if val, ok := lookupEnvInt("DRAW_INTERVAL_SECONDS"); ok && val > 0 {
+		roomConfig.DrawInterval = time.Duration(val) * time.Second
+	}
 }
 
 // Helper to parse int env var
@@ -80,7 +133,8 @@ func lookupEnvInt(key string) (int, bool) {
 	return n, true
 }
 
-// Join a room (increment current_players and start countdown if first player)
+// Join a room (increment current_players and start countdown if first player),
+// taking the room's stake. The player then selects their card.
 // Returns error "user already in room" or "room is full" when appropriate
 func (s *RoomStore) JoinRoom(ctx context.Context, roomID int64, userID int64) error {
 	tx, err := s.DB.BeginTxx(ctx, nil)
@@ -89,10 +143,10 @@ func (s *RoomStore) JoinRoom(ctx context.Context, roomID int64, userID int64) er
 	}
 	defer tx.Rollback()
 
-	// Check if user already joined this room (available_cards table)
+	// Check if user already joined this room (a held stake)
 	var exists int
 	err = tx.GetContext(ctx, &exists, `
-		SELECT 1 FROM available_cards WHERE room_id = $1 AND selected_by_user_id = $2 LIMIT 1
+		SELECT 1 FROM user_bets WHERE room_id = $1 AND user_id = $2 AND status = 'held' LIMIT 1
 	`, roomID, userID)
 	if err == nil && exists == 1 {
 		return fmt.Errorf("user already in room")
@@ -111,6 +165,11 @@ func (s *RoomStore) JoinRoom(ctx context.Context, roomID int64, userID int64) er
 		return fmt.Errorf("room is full")
 	}
 
+	// Take the stake into the room escrow
+	if err := holdStake(ctx, tx, &room, userID, nil); err != nil {
+		return err
+	}
+
 	// Increment player count and start countdown if conditions met
 	_, err = tx.ExecContext(ctx, `
 		UPDATE bingo_rooms
@@ -131,17 +190,6 @@ func (s *RoomStore) JoinRoom(ctx context.Context, roomID int64, userID int64) er
 		return err
 	}
 
-	// Assign an available card to the user
-	_, err = tx.ExecContext(ctx, `
-		UPDATE available_cards
-		SET selected_by_user_id = $1, is_selected = true
-		WHERE room_id = $2 AND is_selected = false
-		LIMIT 1
-	`, userID, roomID)
-	if err != nil {
-		return err
-	}
-
 	return tx.Commit()
 }
 
@@ -175,13 +223,15 @@ func (s *RoomStore) StartRoom(ctx context.Context, roomID int64) error {
 	return err
 }
 
-// Get players in a room
+// Get players in a room: everyone with a stake in its game, whether or not
+// they have picked their card yet
 func (s *RoomStore) GetRoomPlayers(ctx context.Context, roomID int64) ([]User, error) {
 	var users []User
 	err := s.DB.SelectContext(ctx, &users, `
-		SELECT DISTINCT u.* FROM users u
-		JOIN available_cards ac ON ac.selected_by_user_id = u.id
-		WHERE ac.room_id = $1 AND ac.is_selected = true
+		SELECT u.* FROM users u
+		JOIN user_bets ub ON ub.user_id = u.id
+		WHERE ub.room_id = $1 AND ub.status IN ('held', 'settled')
+		ORDER BY ub.id
 	`, roomID)
 
 	fmt.Printf("GetRoomPlayers: roomID=%d, found %d players\n", roomID, len(users))
@@ -199,25 +249,16 @@ func (s *RoomStore) GetRoomCards(ctx context.Context, roomID int64) ([]BingoCard
 	return cards, err
 }
 
-// Place a bet (insert into user_bets)
-func (s *RoomStore) PlaceBet(ctx context.Context, userID, roomID, cardID int64, betAmount float64) error {
-	_, err := s.DB.ExecContext(ctx, `
-		INSERT INTO user_bets (user_id, room_id, bingo_card_id, bet_amount)
-		VALUES ($1, $2, $3, $4)
-	`, userID, roomID, cardID, betAmount)
-	return err
-}
-
-// Find or create a room with the specified bet amount
-func (s *RoomStore) FindOrCreateRoom(ctx context.Context, betAmount float64) (*BingoRoom, error) {
+// Find or create a room of the variant with the specified bet amount
+func (s *RoomStore) FindOrCreateRoom(ctx context.Context, betAmount Money, variant game.Variant) (*BingoRoom, error) {
 	// First, try to find an existing room with the same bet amount that has space
 	var room BingoRoom
 	err := s.DB.GetContext(ctx, &room, `
 		SELECT * FROM bingo_rooms 
-		WHERE bet_amount = $1 AND status = 'waiting' AND current_players < max_players 
+		WHERE bet_amount = $1 AND variant = $2 AND status = 'waiting' AND current_players < max_players 
 		ORDER BY created_at ASC 
 		LIMIT 1
-	`, betAmount)
+	`, betAmount, variant.Name())
 
 	if err == nil {
 		// Found an existing room
@@ -225,7 +266,7 @@ func (s *RoomStore) FindOrCreateRoom(ctx context.Context, betAmount float64) (*B
 	}
 
 	// No existing room found, create a new one with maxPlayers=100
-	newRoom, err := s.CreateRoom(ctx, betAmount, 100)
+	newRoom, err := s.CreateRoom(ctx, betAmount, 100, variant)
 	if err != nil {
 		return nil, err
 	}
@@ -271,10 +312,28 @@ func (s *RoomStore) GetCountdownInfo(ctx context.Context, roomID int64) (*Countd
 	return info, nil
 }
 
-// Remove a user from a room: unselect their card, delete their bingo_card, and decrement player count
+// Remove a user from a room: unselect their card, delete their bingo_card, and decrement player count.
+// A stake is refunded if the room has not started yet; once it has, the stake stays in the pot.
 func (s *RoomStore) RemoveUserFromRoom(ctx context.Context, roomID, userID int64) error {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.GetContext(ctx, &status, `SELECT status FROM bingo_rooms WHERE id = $1 FOR UPDATE`, roomID)
+	if err != nil {
+		return err
+	}
+	if status == "waiting" {
+		if err := refundStake(ctx, tx, roomID, userID); err != nil {
+			return err
+		}
+	}
+
 	// Unselect the user's card
-	_, err := s.DB.ExecContext(ctx, `
+	_, err = tx.ExecContext(ctx, `
 		UPDATE available_cards
 		SET is_selected = false, selected_by_user_id = NULL
 		WHERE room_id = $1 AND selected_by_user_id = $2
@@ -283,12 +342,30 @@ func (s *RoomStore) RemoveUserFromRoom(ctx context.Context, roomID, userID int64
 		return err
 	}
 	// Optionally, remove from bingo_cards
-	_, err = s.DB.ExecContext(ctx, `
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_bets SET bingo_card_id = NULL WHERE room_id = $1 AND user_id = $2
+	`, roomID, userID)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"bingo_claims", "winners"} {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE `+table+` SET bingo_card_id = NULL
+			WHERE user_id = $2 AND bingo_card_id IN (SELECT id FROM bingo_cards WHERE room_id = $1 AND user_id = $2)
+		`, roomID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
 		DELETE FROM bingo_cards WHERE room_id = $1 AND user_id = $2
 	`, roomID, userID)
 	if err != nil {
 		return err
 	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
 	// Decrement player count
 	return s.LeaveRoom(ctx, roomID)
 }
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ServiceNonceStore struct {
+	DB *sqlx.DB
+}
+
+func NewServiceNonceStore(db *sqlx.DB) *ServiceNonceStore {
+	return &ServiceNonceStore{DB: db}
+}
+
+// Use records the nonce of a signed service request until expiresAt and
+// reports whether it was unused. Expired nonces are cleared as it goes.
+func (s *ServiceNonceStore) Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	if _, err := s.DB.ExecContext(ctx, `DELETE FROM service_nonces WHERE expires_at < NOW()`); err != nil {
+		return false, err
+	}
+	res, err := s.DB.ExecContext(ctx, `
+		INSERT INTO service_nonces (nonce, expires_at) VALUES ($1, $2)
+		ON CONFLICT (nonce) DO NOTHING
+	`, nonce, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -22,7 +22,9 @@ func NewSessionStore(db *sqlx.DB) *SessionStore {
 	return &SessionStore{DB: db}
 }
 
-// Start a new game session
+// Start a new game session for a waiting room, or return the room's active
+// session if it has one. Returns ErrRoomNotWaiting for a room whose game is
+// over.
 func (s *SessionStore) StartSession(ctx context.Context, roomID int64) (*GameSession, error) {
 	tx, err := s.DB.BeginTxx(ctx, nil)
 	if err != nil {
@@ -46,9 +48,16 @@ func (s *SessionStore) StartSession(ctx context.Context, roomID int64) (*GameSes
 		log.Printf("[StartSession] Skipped: Active session already exists for room %d (session %d)", roomID, existing.ID)
 		return &existing, nil
 	}
+	if room.Status != RoomWaiting {
+		return nil, ErrRoomNotWaiting
+	}
 
 	log.Printf("[StartSession] Creating new session for room %d", roomID)
-	callouts := game.GenerateCallouts()
+	variant, err := room.CardVariant()
+	if err != nil {
+		return nil, err
+	}
+	callouts := game.GenerateCallouts(variant)
 	remaining, err := json.Marshal(callouts)
 	if err != nil {
 		return nil, err
@@ -58,12 +67,34 @@ func (s *SessionStore) StartSession(ctx context.Context, roomID int64) (*GameSes
 		return nil, err
 	}
 
+	// The session keeps a copy of its prize stages; the first one is live
+	stages, err := resolveStages(ctx, tx, &room)
+	if err != nil {
+		return nil, err
+	}
+	prizeStages, err := json.Marshal(stages)
+	if err != nil {
+		return nil, err
+	}
+	winPattern, err := json.Marshal(stages[0].Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Carry in any pot rolled over from earlier sessions of the tier
+	carried, err := claimRollover(ctx, tx, &room)
+	if err != nil {
+		return nil, err
+	}
+
+	// The draw scheduler draws the first number one interval after the start
 	var session GameSession
 	err = tx.GetContext(ctx, &session, `
-		INSERT INTO game_sessions (room_id, session_start_time, status, drawn_numbers, remaining_numbers, created_at)
-		VALUES ($1, $2, 'active', $3, $4, NOW())
+		INSERT INTO game_sessions (room_id, session_start_time, status, drawn_numbers, remaining_numbers, carried_pot, created_at,
+			next_draw_at, win_pattern, prize_stages, current_stage, pot)
+		VALUES ($1, $2, 'active', $3, $4, $5, NOW(), NOW() + $6 * INTERVAL '1 second', $7, $8, 1, $5)
 		RETURNING *
-	`, roomID, time.Now(), drawn, remaining)
+	`, roomID, time.Now(), drawn, remaining, carried, room.DrawIntervalSeconds, winPattern, prizeStages)
 	if err != nil {
 		log.Printf("[StartSession] Error creating session for room %d: %v", roomID, err)
 		return nil, err
@@ -86,18 +117,51 @@ func (s *SessionStore) StartSession(ctx context.Context, roomID int64) (*GameSes
 	return &session, nil
 }
 
-// Get session by ID
+// Get session by ID, settling it first if its claim window has passed
 func (s *SessionStore) GetSession(ctx context.Context, id int64) (*GameSession, error) {
 	var session GameSession
 	err := s.DB.GetContext(ctx, &session, `SELECT * FROM game_sessions WHERE id = $1`, id)
 	if err != nil {
 		return nil, err
 	}
+	if claimsDue(&session) {
+		if err := s.SettleClaims(ctx, id); err != nil {
+			return nil, err
+		}
+		err = s.DB.GetContext(ctx, &session, `SELECT * FROM game_sessions WHERE id = $1`, id)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &session, nil
 }
 
-// Draw a number for a session
+// Draw a number for a session now, whatever its schedule
 func (s *SessionStore) DrawNumber(ctx context.Context, sessionID int64) (int, error) {
+	return s.drawNumber(ctx, sessionID, false)
+}
+
+// DrawScheduled draws the number a session is due, or settles its claims
+// once its claim window has passed. Returns ErrDrawNotDue if another draw
+// got there first, so each scheduled draw happens once however many
+// schedulers run.
+func (s *SessionStore) DrawScheduled(ctx context.Context, sessionID int64) (int, error) {
+	return s.drawNumber(ctx, sessionID, true)
+}
+
+// DueDraws lists the active sessions with a draw due or a claim window to settle
+func (s *SessionStore) DueDraws(ctx context.Context) ([]int64, error) {
+	var ids []int64
+	err := s.DB.SelectContext(ctx, &ids, `
+		SELECT id FROM game_sessions
+		WHERE status = 'active'
+			AND ((claim_deadline IS NULL AND next_draw_at <= NOW()) OR claim_deadline <= NOW())
+		ORDER BY next_draw_at
+	`)
+	return ids, err
+}
+
+func (s *SessionStore) drawNumber(ctx context.Context, sessionID int64, scheduled bool) (int, error) {
 	tx, err := s.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, err
@@ -110,21 +174,42 @@ func (s *SessionStore) DrawNumber(ctx context.Context, sessionID int64) (int, er
 	if err != nil {
 		return 0, err
 	}
+	if session.Status != "active" {
+		return 0, ErrSessionEnded
+	}
+	// No more numbers once bingo has been called, until the stage is settled
+	if session.ClaimDeadline != nil {
+		if time.Now().Before(*session.ClaimDeadline) {
+			return 0, ErrClaimWindowOpen
+		}
+		ended, err := settleClaims(ctx, tx, &session)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		if ended {
+			return 0, ErrSessionEnded
+		}
+		return 0, ErrStageWon
+	}
+	if scheduled && (session.NextDrawAt == nil || time.Now().Before(*session.NextDrawAt)) {
+		return 0, ErrDrawNotDue
+	}
 
 	var remaining []int
 	if err := json.Unmarshal(session.RemainingNumbers, &remaining); err != nil {
 		return 0, err
 	}
 	if len(remaining) == 0 {
-		// Mark session as completed due to no numbers left
-		_, err := tx.ExecContext(ctx, `
-			UPDATE game_sessions
-			SET status = 'completed', session_end_time = NOW()
-			WHERE id = $1
-		`, sessionID)
-		if err != nil {
+		// Mark session as completed due to no numbers left and settle the pot
+		if err := settleNoWinner(ctx, tx, &session); err != nil {
 			return 0, fmt.Errorf("failed to end session as draw: %w", err)
 		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
 
 		return 0, errors.New("draw: all numbers drawn, session ended with no winner")
 	}
@@ -152,10 +237,11 @@ func (s *SessionStore) DrawNumber(ctx context.Context, sessionID int64) (int, er
 		return 0, err
 	}
 
-	// Update DB inside the transaction
+	// Update DB inside the transaction, scheduling the next draw from now
 	_, err = tx.ExecContext(ctx, `
 		UPDATE game_sessions 
-		SET drawn_numbers = $1, remaining_numbers = $2
+		SET drawn_numbers = $1, remaining_numbers = $2,
+			next_draw_at = NOW() + (SELECT draw_interval_seconds FROM bingo_rooms WHERE id = game_sessions.room_id) * INTERVAL '1 second'
 		WHERE id = $3
 	`, newDrawn, newRemaining, sessionID)
 	if err != nil {
@@ -217,7 +303,9 @@ func (s *SessionStore) MarkNumberOnCard(ctx context.Context, userID int64, cardN
 	return tx.Commit()
 }
 
-// Claim bingo and distribute winnings
+// Claim bingo for the live prize stage. A valid claim is recorded and the
+// first one opens the stage's claim window; the stage's prize is split among
+// every claim once the window has passed.
 func (s *SessionStore) ClaimBingo(ctx context.Context, userID int64, cardNumber int) error {
 	tx, err := s.DB.BeginTxx(ctx, nil)
 	if err != nil {
@@ -247,18 +335,46 @@ func (s *SessionStore) ClaimBingo(ctx context.Context, userID int64, cardNumber
 	}
 	sessionID := session.ID
 
-	// Get the card data
-	var cardData []byte
-	err = tx.GetContext(ctx, &cardData, `
-		SELECT card_data FROM available_cards 
-		WHERE selected_by_user_id = $1 AND card_number = $2
-	`, userID, cardNumber)
+	// Claims for a stage are no longer accepted once its window has passed;
+	// if another stage follows, the claim is for that one
+	if session.ClaimDeadline != nil && !time.Now().Before(*session.ClaimDeadline) {
+		ended, err := settleClaims(ctx, tx, &session)
+		if err != nil {
+			return err
+		}
+		if ended {
+			if err := tx.Commit(); err != nil {
+				return err
+			}
+			return ErrClaimWindowClosed
+		}
+		if err := tx.GetContext(ctx, &session, `SELECT * FROM game_sessions WHERE id = $1`, sessionID); err != nil {
+			return err
+		}
+	}
+
+	// The claim is for the card the user's stake in this game paid for
+	var staked struct {
+		ID       int64  `db:"id"`
+		CardData []byte `db:"card_data"`
+	}
+	err = tx.GetContext(ctx, &staked, `
+		SELECT bc.id, bc.card_data FROM user_bets ub
+		JOIN bingo_cards bc ON bc.id = ub.bingo_card_id
+		JOIN available_cards ac ON ac.room_id = ub.room_id AND ac.selected_by_user_id = ub.user_id AND ac.card_number = $3
+		WHERE ub.room_id = $1 AND ub.user_id = $2
+			AND (ub.status = 'held' OR (ub.status = 'settled' AND ub.settled_at >= $4))
+		ORDER BY ub.id DESC LIMIT 1
+	`, roomID, userID, cardNumber, session.SessionStartTime)
+	if err == sql.ErrNoRows {
+		return ErrCardNotStaked
+	}
 	if err != nil {
 		return err
 	}
 
 	var card game.Card
-	if err := json.Unmarshal(cardData, &card); err != nil {
+	if err := json.Unmarshal(staked.CardData, &card); err != nil {
 		return fmt.Errorf("failed to parse card data: %v", err)
 	}
 
@@ -267,8 +383,13 @@ func (s *SessionStore) ClaimBingo(ctx context.Context, userID int64, cardNumber
 		return fmt.Errorf("failed to parse drawn numbers: %v", err)
 	}
 
+	pattern, err := sessionPattern(&session)
+	if err != nil {
+		return fmt.Errorf("failed to parse win pattern: %v", err)
+	}
+
 	// Handle invalid bingo claim by kicking the user from the room
-	if !card.ValidateBingo(drawnNumbers) {
+	if !card.ValidateBingo(drawnNumbers, pattern) {
 		// Unassign the user's selected card(s) in the room
 		_, err = tx.ExecContext(ctx, `
 			UPDATE available_cards 
@@ -288,83 +409,60 @@ func (s *SessionStore) ClaimBingo(ctx context.Context, userID int64, cardNumber
 		return fmt.Errorf("invalid bingo claim: user has been removed from the room")
 	}
 
-	// Get room bet amount
-	var betAmount float64
-	err = tx.GetContext(ctx, &betAmount, `
-		SELECT bet_amount FROM bingo_rooms WHERE id = $1
-	`, roomID)
-	if err != nil {
-		return err
-	}
-
-	// Count players
-	var playerCount int
-	err = tx.GetContext(ctx, &playerCount, `
-		SELECT COUNT(DISTINCT selected_by_user_id) FROM available_cards 
-		WHERE room_id = $1 AND is_selected = TRUE
-	`, roomID)
+	// Only cards completed by the latest number share the prize. A stage
+	// that went live after the latest number was drawn takes any card.
+	stageStart, err := stageStartDraw(ctx, tx, &session)
 	if err != nil {
 		return err
 	}
-
-	totalPot := betAmount * float64(playerCount)
-	winningAmount := totalPot // Winner takes all
-
-	// Get bingo_card_id
-	var bingoCardID int64
-	err = tx.GetContext(ctx, &bingoCardID, `
-		SELECT id FROM bingo_cards 
-		WHERE user_id = $1 AND room_id = $2
-	`, userID, roomID)
-	if err != nil {
-		return err
+	if last := len(drawnNumbers) - 1; last >= stageStart && pattern.Matches(card.Covered(drawnNumbers[:last])) {
+		return ErrLateClaim
 	}
 
-	// Save winner info
+	// Record the claim for the live stage; the first one opens its claim window
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO winners (session_id, user_id, bingo_card_id, winnings, won_at)
-		VALUES ($1, $2, $3, $4, NOW())
-	`, sessionID, userID, bingoCardID, winningAmount)
+		INSERT INTO bingo_claims (session_id, user_id, bingo_card_id, card_number, draw_count, stage)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (session_id, stage, user_id) DO NOTHING
+	`, sessionID, userID, staked.ID, cardNumber, len(drawnNumbers), session.CurrentStage)
 	if err != nil {
 		return err
 	}
-
-	// Update wallet
-	_, err = tx.ExecContext(ctx, `
-		UPDATE wallets 
-		SET balance = balance + $1, updated_at = NOW() 
-		WHERE user_id = $2
-	`, winningAmount, userID)
-	if err != nil {
-		return err
-	}
-
-	// Add transaction log
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO transactions (user_id, type, amount, created_at)
-		VALUES ($1, 'win', $2, NOW())
-	`, userID, winningAmount)
-	if err != nil {
-		return err
-	}
-
-	// End session
-	_, err = tx.ExecContext(ctx, `
-		UPDATE game_sessions 
-		SET status = 'completed', session_end_time = NOW() 
-		WHERE id = $1
-	`, sessionID)
-	if err != nil {
-		return err
+	if session.ClaimDeadline == nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE game_sessions SET claim_deadline = $2 WHERE id = $1
+		`, sessionID, time.Now().Add(roomConfig.ClaimWindow))
+		if err != nil {
+			return err
+		}
+		log.Printf("[ClaimBingo] Claim window opened for session %d stage %d by user %d", sessionID, session.CurrentStage, userID)
 	}
 
 	return tx.Commit()
 }
 
-// Get winners for a session
+// stageStartDraw returns how many numbers had been drawn when the session's
+// live stage went live: none for the first stage, otherwise as many as when
+// the previous stage was claimed, since no numbers are drawn while claims are
+// collected.
+func stageStartDraw(ctx context.Context, tx *sqlx.Tx, session *GameSession) (int, error) {
+	if session.CurrentStage <= 1 {
+		return 0, nil
+	}
+	var count int
+	err := tx.GetContext(ctx, &count, `
+		SELECT COALESCE(MAX(draw_count), 0) FROM bingo_claims WHERE session_id = $1 AND stage = $2
+	`, session.ID, session.CurrentStage-1)
+	return count, err
+}
+
+// Get winners for a session, every co-winner of each stage with their share
 func (s *SessionStore) GetWinners(ctx context.Context, sessionID int64) ([]Winner, error) {
+	if _, err := s.GetSession(ctx, sessionID); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
 	var winners []Winner
-	err := s.DB.SelectContext(ctx, &winners, `SELECT * FROM winners WHERE session_id = $1`, sessionID)
+	err := s.DB.SelectContext(ctx, &winners, `SELECT * FROM winners WHERE session_id = $1 ORDER BY stage, won_at, id`, sessionID)
 	return winners, err
 }
 
@@ -380,5 +478,8 @@ func (s *SessionStore) GetLatestSessionForRoom(ctx context.Context, roomID int64
 	if err != nil {
 		return nil, err
 	}
+	if claimsDue(&session) {
+		return s.GetSession(ctx, session.ID)
+	}
 	return &session, nil
 }
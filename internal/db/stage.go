@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"rockbingo/internal/game"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxPrizeStages caps how many prize stages a room may have
+const maxPrizeStages = 10
+
+// ErrStageWon is returned when drawing for a session whose live stage was
+// just settled; the next stage starts with the following draw
+var ErrStageWon = errors.New("the prize stage has been won; the next stage is live")
+
+// RoomStage is a prize stage as a room is configured: the name of the
+// pattern that wins it and its share of the pot in basis points
+type RoomStage struct {
+	Pattern  string `json:"pattern"`
+	ShareBps int    `json:"share_bps"`
+}
+
+// PrizeStage is a prize stage as a session plays it, numbered from 1
+type PrizeStage struct {
+	Stage    int          `json:"stage"`
+	Pattern  game.Pattern `json:"pattern"`
+	ShareBps int          `json:"share_bps"`
+}
+
+// ValidateStages checks the prize stages of a room: 1 to 10 stages, each
+// with a pattern and a positive share, the shares adding up to the whole pot
+func ValidateStages(stages []RoomStage) error {
+	if len(stages) == 0 || len(stages) > maxPrizeStages {
+		return fmt.Errorf("a room needs 1 to %d prize stages", maxPrizeStages)
+	}
+	total := 0
+	for i, st := range stages {
+		if st.Pattern == "" {
+			return fmt.Errorf("stage %d needs a pattern", i+1)
+		}
+		if st.ShareBps <= 0 {
+			return fmt.Errorf("stage %d needs a positive share_bps", i+1)
+		}
+		total += st.ShareBps
+	}
+	if total != 10000 {
+		return errors.New("the share_bps of the stages must add up to 10000")
+	}
+	return nil
+}
+
+// resolveStages returns the prize stages a room's next game is played with.
+// A room without stages has a single one, won with its win pattern.
+func resolveStages(ctx context.Context, q sqlx.QueryerContext, room *BingoRoom) ([]PrizeStage, error) {
+	configured := []RoomStage{{Pattern: room.WinPattern, ShareBps: 10000}}
+	if len(room.PrizeStages) > 0 {
+		if err := json.Unmarshal(room.PrizeStages, &configured); err != nil {
+			return nil, err
+		}
+	}
+	stages := make([]PrizeStage, 0, len(configured))
+	for i, st := range configured {
+		p, err := lookupPattern(ctx, q, room.Variant, st.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		stages = append(stages, PrizeStage{Stage: i + 1, Pattern: p, ShareBps: st.ShareBps})
+	}
+	return stages, nil
+}
+
+// sessionStages returns the prize stages of a session. Sessions started
+// before stages existed have a single one, won with their win pattern.
+func sessionStages(session *GameSession) ([]PrizeStage, error) {
+	if len(session.PrizeStages) > 0 {
+		var stages []PrizeStage
+		if err := json.Unmarshal(session.PrizeStages, &stages); err != nil {
+			return nil, err
+		}
+		return stages, nil
+	}
+	p, err := sessionPattern(session)
+	if err != nil {
+		return nil, err
+	}
+	return []PrizeStage{{Stage: 1, Pattern: p, ShareBps: 10000}}, nil
+}
+
+// sessionPaid returns what has been paid out of a session's pot so far,
+// winnings and rake
+func sessionPaid(ctx context.Context, tx *sqlx.Tx, sessionID int64) (Money, error) {
+	var paid Money
+	err := tx.GetContext(ctx, &paid, `
+		SELECT COALESCE(SUM(winnings + rake), 0) FROM winners WHERE session_id = $1
+	`, sessionID)
+	return paid, err
+}
+
+// Set the prize stages of a room that has not started yet. The room's win
+// pattern becomes that of the first stage.
+func (s *RoomStore) SetRoomStages(ctx context.Context, roomID int64, stages []RoomStage) (*BingoRoom, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	variant, err := roomVariant(ctx, tx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stages {
+		if _, err := lookupPattern(ctx, tx, variant, st.Pattern); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(stages)
+	if err != nil {
+		return nil, err
+	}
+	var room BingoRoom
+	err = tx.GetContext(ctx, &room, `
+		UPDATE bingo_rooms SET prize_stages = $2, win_pattern = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'waiting'
+		RETURNING *
+	`, roomID, data, stages[0].Pattern)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoomNotWaiting
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// settleUnclaimed ends a session whose numbers ran out after some of its
+// stages were won. What is left of the pot, with any stakes held since,
+// goes to the rollover pool or jackpot by the room's policy; with the
+// refund policy it is shared out evenly among the session's players, as
+// winnings are, or goes to the jackpot if it has none.
+func settleUnclaimed(ctx context.Context, tx *sqlx.Tx, session *GameSession, room *BingoRoom, policy string) (string, Money, error) {
+	bets, stakes, err := closeStakes(ctx, tx, room.ID, BetSettled)
+	if err != nil {
+		return "", Money{}, err
+	}
+	paid, err := sessionPaid(ctx, tx, session.ID)
+	if err != nil {
+		return "", Money{}, err
+	}
+	left := session.Pot.Add(stakes).Sub(paid)
+	memo := fmt.Sprintf("session %d", session.ID)
+	src := Source{RoomID: room.ID, SessionID: session.ID}
+
+	var players []int64
+	if policy == NoWinnerRefund {
+		err = tx.SelectContext(ctx, &players, `
+			SELECT DISTINCT user_id FROM user_bets
+			WHERE room_id = $1 AND status = 'settled' AND settled_at >= $2
+			ORDER BY user_id
+		`, room.ID, session.SessionStartTime)
+		if err != nil {
+			return "", Money{}, err
+		}
+	}
+	if policy == NoWinnerRefund && len(players) > 0 {
+		if left.IsPositive() {
+			share, remainder := left.Split(int64(len(players)))
+			j := Journal{Kind: JournalRefund, Memo: memo, Source: src,
+				Postings: []Posting{{Account: RoomEscrowAccount(room.ID), Amount: left.Neg()}}}
+			for i, userID := range players {
+				amount := share
+				if int64(i) < remainder.Minor {
+					amount = amount.Add(NewMoney(1, amount.Cur()))
+				}
+				postings, err := payoutPostings(ctx, tx, session, userID, amount)
+				if err != nil {
+					return "", Money{}, err
+				}
+				j.Postings = append(j.Postings, postings...)
+			}
+			if _, err := postJournal(ctx, tx, j); err != nil {
+				return "", Money{}, err
+			}
+		}
+		return OutcomeRefunded, left, nil
+	}
+
+	kind, to, outcome := JournalJackpot, JackpotAccount(), OutcomeJackpot
+	if policy == NoWinnerRollover {
+		kind, to, outcome = JournalRollover, TierRolloverAccount(*room.TierID), OutcomeRolledOver
+	}
+	if left.IsPositive() {
+		journalID, err := postJournal(ctx, tx, Transfer(kind, memo, RoomEscrowAccount(room.ID), to, left).WithSource(src))
+		if err != nil {
+			return "", Money{}, err
+		}
+		if err := recordStakeTransactions(ctx, tx, journalID, kind, bets, src); err != nil {
+			return "", Money{}, err
+		}
+	}
+	return outcome, left, nil
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// What happens to the pot of a session that ends without a winner
+const (
+	NoWinnerRefund   = "refund"
+	NoWinnerRollover = "rollover"
+	NoWinnerJackpot  = "jackpot"
+)
+
+// ValidNoWinnerPolicy reports whether policy is a known no-winner policy
+func ValidNoWinnerPolicy(policy string) bool {
+	switch policy {
+	case NoWinnerRefund, NoWinnerRollover, NoWinnerJackpot:
+		return true
+	}
+	return false
+}
+
+// Session outcomes
+const (
+	OutcomeWon        = "won"
+	OutcomeRefunded   = "refunded"
+	OutcomeRolledOver = "rolled_over"
+	OutcomeJackpot    = "jackpot"
+)
+
+type TierStore struct {
+	DB *sqlx.DB
+}
+
+func NewTierStore(db *sqlx.DB) *TierStore {
+	return &TierStore{DB: db}
+}
+
+// List all room tiers
+func (s *TierStore) ListTiers(ctx context.Context) ([]RoomTier, error) {
+	var tiers []RoomTier
+	err := s.DB.SelectContext(ctx, &tiers, `SELECT * FROM room_tiers ORDER BY bet_amount`)
+	return tiers, err
+}
+
+// Create or update the tier for a bet amount. Rooms created afterwards use
+// the new settings; existing rooms keep the ones they were created with.
+func (s *TierStore) UpsertTier(ctx context.Context, betAmount Money, noWinnerPolicy string, rake RakeConfig) (*RoomTier, error) {
+	var tier RoomTier
+	err := s.DB.GetContext(ctx, &tier, `
+		INSERT INTO room_tiers (bet_amount, no_winner_policy, rake_bps, rake_min, rake_cap)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (bet_amount) DO UPDATE
+		SET no_winner_policy = EXCLUDED.no_winner_policy,
+			rake_bps = EXCLUDED.rake_bps,
+			rake_min = EXCLUDED.rake_min,
+			rake_cap = EXCLUDED.rake_cap,
+			updated_at = NOW()
+		RETURNING *
+	`, betAmount, noWinnerPolicy, rake.RakeBps, rake.RakeMin, rake.RakeCap)
+	if err != nil {
+		return nil, err
+	}
+	return &tier, nil
+}
+
+// claimRollover moves a tier's rolled-over pot into the escrow of a room that
+// is starting and returns the amount carried into its session
+func claimRollover(ctx context.Context, tx *sqlx.Tx, room *BingoRoom) (Money, error) {
+	carried := NewMoney(0, DefaultCurrency)
+	if room.TierID == nil {
+		return carried, nil
+	}
+	// Serialize rooms of the tier starting at the same time
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM room_tiers WHERE id = $1 FOR UPDATE`, *room.TierID); err != nil {
+		return carried, err
+	}
+	pool, err := ledgerBalance(ctx, tx, TierRolloverAccount(*room.TierID))
+	if err != nil || !pool.IsPositive() {
+		return carried, err
+	}
+	_, err = postJournal(ctx, tx, Transfer(JournalRollover, fmt.Sprintf("carried into room %d", room.ID),
+		TierRolloverAccount(*room.TierID), RoomEscrowAccount(room.ID), pool).WithSource(Source{RoomID: room.ID}))
+	if err != nil {
+		return carried, err
+	}
+	return pool, nil
+}
+
+// awardJackpot pays the whole jackpot to the winners of the final stage of a
+// session played in a jackpot room. The jackpot is fed by the pots of
+// jackpot rooms nobody won; it is split evenly, without rake, the minor
+// units left over going one each to the earliest claims.
+func awardJackpot(ctx context.Context, tx *sqlx.Tx, session *GameSession, stage int, claims []BingoClaim) error {
+	// Serialize sessions winning the jackpot at the same time
+	accountID, err := ledgerAccountID(ctx, tx, JackpotAccount(), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM ledger_accounts WHERE id = $1 FOR UPDATE`, accountID); err != nil {
+		return err
+	}
+	jackpot, err := ledgerBalance(ctx, tx, JackpotAccount())
+	if err != nil || !jackpot.IsPositive() {
+		return err
+	}
+
+	share, remainder := jackpot.Split(int64(len(claims)))
+	j := Journal{
+		Kind:     JournalJackpot,
+		Memo:     fmt.Sprintf("jackpot won in session %d", session.ID),
+		Source:   Source{RoomID: session.RoomID, SessionID: session.ID},
+		Postings: []Posting{{Account: JackpotAccount(), Amount: jackpot.Neg()}},
+	}
+	for i, claim := range claims {
+		amount := share
+		if int64(i) < remainder.Minor {
+			amount = amount.Add(NewMoney(1, amount.Cur()))
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE winners SET jackpot = $4 WHERE session_id = $1 AND user_id = $2 AND stage = $3
+		`, session.ID, claim.UserID, stage, amount)
+		if err != nil {
+			return err
+		}
+		postings, err := payoutPostings(ctx, tx, session, claim.UserID, amount)
+		if err != nil {
+			return err
+		}
+		j.Postings = append(j.Postings, postings...)
+	}
+	if _, err := postJournal(ctx, tx, j); err != nil {
+		return err
+	}
+	log.Printf("[awardJackpot] Session %d won the jackpot of %s, split among %d winner(s)", session.ID, jackpot, len(claims))
+	return nil
+}
+
+// settleNoWinner ends a session nobody won and settles its pot by the room's
+// no-winner policy: stakes are refunded, or the pot is rolled over to the
+// next session of the tier or moved to the jackpot. A pot carried into a
+// refunded session goes back to the tier's rollover pool. When earlier
+// prize stages were won, what is left is settled by settleUnclaimed.
+func settleNoWinner(ctx context.Context, tx *sqlx.Tx, session *GameSession) error {
+	var room BingoRoom
+	err := tx.GetContext(ctx, &room, `SELECT * FROM bingo_rooms WHERE id = $1 FOR UPDATE`, session.RoomID)
+	if err != nil {
+		return err
+	}
+	policy := room.NoWinnerPolicy
+	if policy == NoWinnerRollover && room.TierID == nil {
+		policy = NoWinnerRefund
+	}
+
+	var outcome string
+	var amount Money
+	memo := fmt.Sprintf("session %d", session.ID)
+	src := Source{RoomID: room.ID, SessionID: session.ID}
+	switch {
+	case session.CurrentStage > 1:
+		// A pot that earlier stages were paid from can't be refunded stake by stake
+		outcome, amount, err = settleUnclaimed(ctx, tx, session, &room, policy)
+		if err != nil {
+			return err
+		}
+	case policy == NoWinnerRollover, policy == NoWinnerJackpot:
+		bets, stakes, err := closeStakes(ctx, tx, room.ID, BetSettled)
+		if err != nil {
+			return err
+		}
+		amount = stakes.Add(session.CarriedPot)
+		kind, to := JournalJackpot, JackpotAccount()
+		outcome = OutcomeJackpot
+		if policy == NoWinnerRollover {
+			kind, to = JournalRollover, TierRolloverAccount(*room.TierID)
+			outcome = OutcomeRolledOver
+		}
+		if amount.IsPositive() {
+			journalID, err := postJournal(ctx, tx, Transfer(kind, memo, RoomEscrowAccount(room.ID), to, amount).WithSource(src))
+			if err != nil {
+				return err
+			}
+			if err := recordStakeTransactions(ctx, tx, journalID, kind, bets, src); err != nil {
+				return err
+			}
+		}
+	default:
+		bets, stakes, err := closeStakes(ctx, tx, room.ID, BetRefunded)
+		if err != nil {
+			return err
+		}
+		if err := refundBets(ctx, tx, room.ID, bets); err != nil {
+			return err
+		}
+		if session.CarriedPot.IsPositive() && room.TierID != nil {
+			_, err = postJournal(ctx, tx, Transfer(JournalRollover, memo,
+				RoomEscrowAccount(room.ID), TierRolloverAccount(*room.TierID), session.CarriedPot).WithSource(src))
+			if err != nil {
+				return err
+			}
+		}
+		outcome, amount = OutcomeRefunded, stakes
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE game_sessions
+		SET status = 'completed', session_end_time = NOW(), outcome = $2, outcome_amount = $3
+		WHERE id = $1
+	`, session.ID, outcome, amount)
+	return err
+}
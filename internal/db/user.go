@@ -7,6 +7,18 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// User roles, in increasing order of privilege
+const (
+	RolePlayer   = "player"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// ValidRole reports whether role is one of the known user roles
+func ValidRole(role string) bool {
+	return role == RolePlayer || role == RoleOperator || role == RoleAdmin
+}
+
 type UserStore struct {
 	DB *sqlx.DB
 }
@@ -51,6 +63,16 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*User, error) {
 	return &user, nil
 }
 
+// Get user by Telegram ID
+func (s *UserStore) GetByTelegramID(ctx context.Context, telegramID int64) (*User, error) {
+	var user User
+	err := s.DB.GetContext(ctx, &user, `SELECT * FROM users WHERE telegram_id = $1`, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Update user profile
 func (s *UserStore) UpdateProfile(ctx context.Context, id int64, username, firstName, lastName string) error {
 	_, err := s.DB.ExecContext(ctx, `
@@ -58,3 +80,32 @@ func (s *UserStore) UpdateProfile(ctx context.Context, id int64, username, first
 	`, username, firstName, lastName, id)
 	return err
 }
+
+// Revoke every API token issued to a user, e.g. on logout or after a ban
+func (s *UserStore) RevokeTokens(ctx context.Context, id int64) error {
+	_, err := s.DB.ExecContext(ctx, `
+		UPDATE users SET token_version = token_version + 1 WHERE id = $1
+	`, id)
+	return err
+}
+
+// Set a user's role
+func (s *UserStore) SetRole(ctx context.Context, id int64, role string) error {
+	res, err := s.DB.ExecContext(ctx, `UPDATE users SET role = $1 WHERE id = $2`, role, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Grant a role by Telegram ID, creating the user if they have never logged in
+func (s *UserStore) GrantRoleByTelegram(ctx context.Context, telegramID int64, role string) error {
+	user, err := s.FindOrCreateByTelegram(ctx, telegramID, "", "", "")
+	if err != nil {
+		return err
+	}
+	return s.SetRole(ctx, user.ID, role)
+}
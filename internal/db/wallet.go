@@ -2,10 +2,33 @@ package db
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// Deposit statuses
+const (
+	DepositPending   = "pending"
+	DepositCompleted = "completed"
+	DepositFailed    = "failed"
+)
+
+var (
+	// ErrDuplicateTransactionRef is returned when a deposit reuses a transaction_ref
+	ErrDuplicateTransactionRef = errors.New("deposit with this transaction_ref already exists")
+	// ErrDepositAmountMismatch is returned when a provider confirms a different amount than was deposited
+	ErrDepositAmountMismatch = errors.New("confirmed amount does not match the deposit")
+)
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 type WalletStore struct {
 	DB *sqlx.DB
 }
@@ -24,23 +47,163 @@ func (s *WalletStore) GetWallet(ctx context.Context, userID int64) (*Wallet, err
 	return &wallet, nil
 }
 
-// Get transactions by user ID
-func (s *WalletStore) GetTransactions(ctx context.Context, userID int64) ([]Transaction, error) {
+// ValidTransactionType reports whether transactions can be listed under kind
+func ValidTransactionType(kind string) bool {
+	switch kind {
+	case JournalDeposit, JournalWithdraw, JournalBet, JournalWin, JournalRefund,
+		JournalRollover, JournalJackpot, JournalWithdrawReturn, JournalBonus, JournalBonusRelease,
+		JournalReferral:
+		return true
+	}
+	return false
+}
+
+// TransactionFilter narrows a transaction history. Zero fields match
+// everything; From is inclusive and To exclusive.
+type TransactionFilter struct {
+	Types     []string
+	From      *time.Time
+	To        *time.Time
+	RoomID    int64
+	SessionID int64
+	// Before is the pagination cursor: only transactions with a lower ID
+	Before int64
+	// Limit caps the number returned; 0 returns all
+	Limit int
+}
+
+// Get a user's transactions matching filter, newest first
+func (s *WalletStore) GetTransactions(ctx context.Context, userID int64, filter TransactionFilter) ([]Transaction, error) {
 	var txs []Transaction
-	err := s.DB.SelectContext(ctx, &txs, `SELECT * FROM transactions WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	err := s.DB.SelectContext(ctx, &txs, `
+		SELECT * FROM transactions
+		WHERE user_id = $1
+			AND ($2::text[] IS NULL OR type = ANY($2))
+			AND ($3::timestamptz IS NULL OR created_at >= $3)
+			AND ($4::timestamptz IS NULL OR created_at < $4)
+			AND ($5::integer = 0 OR room_id = $5)
+			AND ($6::integer = 0 OR session_id = $6)
+			AND ($7::integer = 0 OR id < $7)
+		ORDER BY id DESC
+		LIMIT NULLIF($8::integer, 0)
+	`, userID, pq.Array(filter.Types), filter.From, filter.To, filter.RoomID, filter.SessionID, filter.Before, filter.Limit)
 	return txs, err
 }
 
-// Create a deposit
-func (s *WalletStore) CreateDeposit(ctx context.Context, userID int64, amount float64, currency, transactionRef, status string) (*PaymentDeposit, error) {
+// Create a pending deposit to be paid through provider. Nothing is credited
+// until the provider confirms the payment (see SettleDeposit). Returns
+// ErrSelfExcluded or an error wrapping ErrDepositLimit if the user's
+// responsible gaming limits do not allow the deposit.
+func (s *WalletStore) CreateDeposit(ctx context.Context, userID int64, amount Money, transactionRef, provider string) (*PaymentDeposit, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockLimits(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if err := checkDepositLimits(ctx, tx, userID, amount); err != nil {
+		return nil, err
+	}
+	var dep PaymentDeposit
+	err = tx.GetContext(ctx, &dep, `
+		INSERT INTO payment_deposits (user_id, amount, currency, transaction_ref, status, provider)
+		VALUES ($1, $2, $3, $4, 'pending', $5)
+		RETURNING *
+	`, userID, amount, string(amount.Cur()), transactionRef, provider)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicateTransactionRef
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &dep, nil
+}
+
+// Record the checkout session a deposit is paid through
+func (s *WalletStore) SetDepositCheckout(ctx context.Context, depositID int64, providerRef, checkoutURL string) (*PaymentDeposit, error) {
 	var dep PaymentDeposit
 	err := s.DB.GetContext(ctx, &dep, `
-		INSERT INTO payment_deposits (user_id, amount, currency, transaction_ref, status)
-		VALUES ($1, $2, $3, $4, $5)
+		UPDATE payment_deposits SET provider_ref = $2, checkout_url = $3, updated_at = NOW()
+		WHERE id = $1
 		RETURNING *
-	`, userID, amount, currency, transactionRef, status)
+	`, depositID, providerRef, checkoutURL)
+	if err != nil {
+		return nil, err
+	}
+	return &dep, nil
+}
+
+// Get deposit by transaction reference
+func (s *WalletStore) GetDepositByRef(ctx context.Context, transactionRef string) (*PaymentDeposit, error) {
+	var dep PaymentDeposit
+	err := s.DB.GetContext(ctx, &dep, `SELECT * FROM payment_deposits WHERE transaction_ref = $1`, transactionRef)
 	if err != nil {
 		return nil, err
 	}
 	return &dep, nil
 }
+
+// SettleDeposit applies the outcome the provider reported for a pending
+// deposit. A completed deposit credits the wallet from gateway clearing with
+// the amount the provider collected, which must match the deposit, credits
+// any deposit match bonus the user redeemed and counts the deposit towards
+// the user's referral. A deposit that is no
+// longer pending is returned unchanged, so a redelivered webhook
+// cannot credit twice.
+func (s *WalletStore) SettleDeposit(ctx context.Context, provider, transactionRef, status string, amount Money) (*PaymentDeposit, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var dep PaymentDeposit
+	err = tx.GetContext(ctx, &dep, `
+		SELECT * FROM payment_deposits WHERE transaction_ref = $1 AND provider = $2 FOR UPDATE
+	`, transactionRef, provider)
+	if err != nil {
+		return nil, err
+	}
+	if dep.Status != DepositPending || status == DepositPending {
+		return &dep, nil
+	}
+
+	if status == DepositCompleted {
+		if amount.Cur() != Currency(dep.Currency) || amount.Cmp(dep.Amount) != 0 {
+			return nil, ErrDepositAmountMismatch
+		}
+		_, err = postJournal(ctx, tx, Transfer(JournalDeposit, "deposit "+transactionRef,
+			GatewayClearingAccount(), UserWalletAccount(dep.UserID), amount).WithSource(Source{DepositID: dep.ID}))
+		if err != nil {
+			return nil, err
+		}
+		if err := applyDepositMatch(ctx, tx, &dep); err != nil {
+			return nil, err
+		}
+		if err := attributeDeposit(ctx, tx, dep.UserID, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.GetContext(ctx, &dep, `
+		UPDATE payment_deposits
+		SET status = $2,
+			completed_at = CASE WHEN $2 = 'completed' THEN NOW() END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, dep.ID, status)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &dep, nil
+}
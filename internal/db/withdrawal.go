@@ -0,0 +1,212 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Withdrawal request statuses
+const (
+	WithdrawalPending    = "pending"
+	WithdrawalApproved   = "approved"
+	WithdrawalRejected   = "rejected"
+	WithdrawalProcessing = "processing"
+	WithdrawalPaid       = "paid"
+	WithdrawalFailed     = "failed"
+)
+
+// withdrawalTransitions lists the statuses a review or payout outcome may
+// move each status to. Sending a payout (approved -> processing) and
+// retrying it (processing -> approved) move no money and are done by
+// StartPayout and RetryPayout.
+var withdrawalTransitions = map[string][]string{
+	WithdrawalPending:    {WithdrawalApproved, WithdrawalRejected},
+	WithdrawalApproved:   {WithdrawalPaid, WithdrawalFailed},
+	WithdrawalProcessing: {WithdrawalPaid, WithdrawalFailed},
+}
+
+// operatorTransitions are the moves operators may make. A processing payout
+// is in the provider's hands, so only the provider's outcome settles it.
+var operatorTransitions = map[string][]string{
+	WithdrawalPending:  {WithdrawalApproved, WithdrawalRejected},
+	WithdrawalApproved: {WithdrawalPaid, WithdrawalFailed},
+}
+
+// ErrInvalidWithdrawalTransition is returned for a status change the workflow does not allow
+var ErrInvalidWithdrawalTransition = errors.New("withdrawal request cannot move to that status")
+
+// ValidWithdrawalStatus reports whether status is a known withdrawal status
+func ValidWithdrawalStatus(status string) bool {
+	switch status {
+	case WithdrawalPending, WithdrawalApproved, WithdrawalRejected, WithdrawalProcessing, WithdrawalPaid, WithdrawalFailed:
+		return true
+	}
+	return false
+}
+
+type WithdrawalStore struct {
+	DB *sqlx.DB
+}
+
+func NewWithdrawalStore(db *sqlx.DB) *WithdrawalStore {
+	return &WithdrawalStore{DB: db}
+}
+
+// Create a pending withdrawal request to one of the user's verified payout
+// methods, holding the amount out of the wallet. Returns
+// ErrPayoutMethodNotFound or ErrPayoutMethodUnverified for a method that
+// cannot be paid to, and ErrInsufficientBalance if the wallet cannot cover it.
+func (s *WithdrawalStore) CreateWithdrawal(ctx context.Context, userID int64, amount Money, payoutMethodID int64) (*WithdrawalRequest, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var method PayoutMethod
+	err = tx.GetContext(ctx, &method, `
+		SELECT * FROM payout_methods WHERE id = $1 AND user_id = $2 AND removed_at IS NULL
+	`, payoutMethodID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPayoutMethodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if method.VerifiedAt == nil {
+		return nil, ErrPayoutMethodUnverified
+	}
+
+	var req WithdrawalRequest
+	err = tx.GetContext(ctx, &req, `
+		INSERT INTO withdrawal_requests (user_id, amount, currency, destination, status, payout_method_id)
+		VALUES ($1, $2, $3, $4, 'pending', $5)
+		RETURNING *
+	`, userID, amount, string(amount.Cur()), method.Destination().String(), method.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = postJournal(ctx, tx, Transfer(JournalWithdraw, fmt.Sprintf("withdrawal request %d", req.ID),
+		UserWalletAccount(userID), WithdrawalHoldAccount(userID), amount).WithSource(Source{WithdrawalID: req.ID}))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Get withdrawal request by ID
+func (s *WithdrawalStore) GetWithdrawal(ctx context.Context, id int64) (*WithdrawalRequest, error) {
+	var req WithdrawalRequest
+	err := s.DB.GetContext(ctx, &req, `SELECT * FROM withdrawal_requests WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Get withdrawal requests by user ID
+func (s *WithdrawalStore) ListUserWithdrawals(ctx context.Context, userID int64) ([]WithdrawalRequest, error) {
+	var reqs []WithdrawalRequest
+	err := s.DB.SelectContext(ctx, &reqs, `
+		SELECT * FROM withdrawal_requests WHERE user_id = $1 ORDER BY created_at DESC
+	`, userID)
+	return reqs, err
+}
+
+// List withdrawal requests, oldest first, optionally only those in status
+func (s *WithdrawalStore) ListWithdrawals(ctx context.Context, status string) ([]WithdrawalRequest, error) {
+	var reqs []WithdrawalRequest
+	err := s.DB.SelectContext(ctx, &reqs, `
+		SELECT * FROM withdrawal_requests
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at ASC
+	`, status)
+	return reqs, err
+}
+
+// Transition moves a withdrawal request to status on behalf of an operator.
+// A paid request releases the held funds to the payment gateway; a rejected
+// or failed one returns them to the wallet. Approved requests with a payout
+// method are sent by the payout dispatcher, but operators may still settle
+// them by hand until they are sent. Returns sql.ErrNoRows for an unknown
+// request and ErrInvalidWithdrawalTransition if the move is not allowed,
+// including for a request whose payout is processing.
+func (s *WithdrawalStore) Transition(ctx context.Context, id int64, status string, operatorID int64, note string) (*WithdrawalRequest, error) {
+	tx, err := s.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	req, err := transitionWithdrawal(ctx, tx, id, status, &operatorID, note)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// transitionWithdrawal applies a status change and its ledger movement inside
+// tx. A nil operatorID records no reviewer, as for payout provider outcomes.
+func transitionWithdrawal(ctx context.Context, tx *sqlx.Tx, id int64, status string, operatorID *int64, note string) (*WithdrawalRequest, error) {
+	var req WithdrawalRequest
+	err := tx.GetContext(ctx, &req, `SELECT * FROM withdrawal_requests WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		return nil, err
+	}
+	transitions := withdrawalTransitions
+	if operatorID != nil {
+		transitions = operatorTransitions
+	}
+	allowed := false
+	for _, next := range transitions[req.Status] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return nil, ErrInvalidWithdrawalTransition
+	}
+
+	memo := fmt.Sprintf("withdrawal request %d", req.ID)
+	src := Source{WithdrawalID: req.ID}
+	switch status {
+	case WithdrawalPaid:
+		_, err = postJournal(ctx, tx, Transfer(JournalWithdrawPaid, memo,
+			WithdrawalHoldAccount(req.UserID), GatewayClearingAccount(), req.Amount).WithSource(src))
+	case WithdrawalRejected, WithdrawalFailed:
+		_, err = postJournal(ctx, tx, Transfer(JournalWithdrawReturn, memo,
+			WithdrawalHoldAccount(req.UserID), UserWalletAccount(req.UserID), req.Amount).WithSource(src))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var notePtr *string
+	if note != "" {
+		notePtr = &note
+	}
+	err = tx.GetContext(ctx, &req, `
+		UPDATE withdrawal_requests
+		SET status = $2,
+			note = COALESCE($3, note),
+			reviewed_by = COALESCE($4::integer, reviewed_by),
+			reviewed_at = CASE WHEN $4::integer IS NULL THEN reviewed_at ELSE NOW() END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, req.ID, status, notePtr, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
@@ -11,52 +11,38 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-// Card represents a Bingo card with its grid and marked numbers.
+// Card represents a Bingo card of any variant with its grid and marked
+// numbers. Empty cells and the free space hold 0. Strip is the set of six
+// a 90-ball ticket was dealt in.
 type Card struct {
-	Grid  [5][5]int  `json:"grid"`
-	Marks [5][5]bool `json:"marks"`
+	Variant string   `json:"variant,omitempty"`
+	Strip   int      `json:"strip,omitempty"`
+	Grid    [][]int  `json:"grid"`
+	Marks   [][]bool `json:"marks"`
 }
 
-// NewCard creates a 5x5 Bingo card with numbers from traditional Bingo ranges per column
-// and center cell marked as free space (0).
-func NewCard() *Card {
-	card := &Card{}
-
-	// Define number ranges per column
-	ranges := [5][2]int{
-		{1, 15},  // B
-		{16, 30}, // I
-		{31, 45}, // N
-		{46, 60}, // G
-		{61, 75}, // O
-	}
-
-	for col := 0; col < 5; col++ {
-		// Generate 5 unique numbers within the column range
-		numRange := ranges[col][1] - ranges[col][0] + 1
-		nums := rand.Perm(numRange)[:5] // first 5 unique numbers from range size
-
-		for row := 0; row < 5; row++ {
-			card.Grid[row][col] = nums[row] + ranges[col][0] // shift by start of range
-		}
+// newGrid returns an empty card of the given size.
+func newGrid(variant string, rows, cols int) *Card {
+	card := &Card{Variant: variant, Grid: make([][]int, rows), Marks: make([][]bool, rows)}
+	for i := range card.Grid {
+		card.Grid[i] = make([]int, cols)
+		card.Marks[i] = make([]bool, cols)
 	}
-
-	// Center cell (2,2) is free space: number = 0 and marked
-	card.Grid[2][2] = 0
-	card.Marks[2][2] = true
-
 	return card
 }
 
-// BingoHeaders returns the BINGO column headers.
-func BingoHeaders() [5]string {
-	return [5]string{"B", "I", "N", "G", "O"}
+// VariantName returns the card's variant; cards from before variants existed are 75-ball.
+func (c *Card) VariantName() string {
+	if c.Variant == "" {
+		return Variant75
+	}
+	return c.Variant
 }
 
 // MarkNumber marks a number on the card if it exists.
 func (c *Card) MarkNumber(number int) {
-	for i := 0; i < 5; i++ {
-		for j := 0; j < 5; j++ {
+	for i := range c.Grid {
+		for j := range c.Grid[i] {
 			if c.Grid[i][j] == number {
 				c.Marks[i][j] = true
 				return
@@ -70,115 +56,41 @@ func (c *Card) ToJSON() ([]byte, error) {
 	return json.Marshal(c)
 }
 
-// GenerateCallouts creates a shuffled list of numbers from 1 to 75.
-func GenerateCallouts() []int {
-	nums := make([]int, 75)
-	for i := 0; i < 75; i++ {
-		nums[i] = i + 1
-	}
-	rand.Shuffle(len(nums), func(i, j int) {
-		nums[i], nums[j] = nums[j], nums[i]
-	})
-	return nums
-}
-
-// GenerateAvailableCards creates 100 unique bingo cards for a room.
-func GenerateAvailableCards() []*Card {
-	cards := make([]*Card, 0, 100)
-	cardSet := make(map[string]bool)
-
-	for len(cards) < 100 {
-		card := NewCard()
-		jsonBytes, err := card.ToJSON()
-		if err != nil {
-			continue // skip invalid cards, rare but safe
-		}
-		key := string(jsonBytes)
-		if !cardSet[key] {
-			cardSet[key] = true
-			cards = append(cards, card)
-		}
-	}
-
-	return cards
-}
-
-// HasWinningPattern checks if the card has a winning bingo pattern.
-func (c *Card) HasWinningPattern() bool {
-	// Check rows
-	for i := 0; i < 5; i++ {
-		rowComplete := true
-		for j := 0; j < 5; j++ {
-			if !c.Marks[i][j] {
-				rowComplete = false
-				break
-			}
-		}
-		if rowComplete {
-			return true
-		}
-	}
-
-	// Check columns
-	for j := 0; j < 5; j++ {
-		colComplete := true
-		for i := 0; i < 5; i++ {
-			if !c.Marks[i][j] {
-				colComplete = false
-				break
-			}
-		}
-		if colComplete {
-			return true
-		}
-	}
-
-	// Check diagonal (top-left to bottom-right)
-	diagComplete := true
-	for i := 0; i < 5; i++ {
-		if !c.Marks[i][i] {
-			diagComplete = false
-			break
-		}
-	}
-	if diagComplete {
-		return true
+// Covered returns the cells of the card whose number has been drawn, and the
+// empty cells and free space.
+func (c *Card) Covered(drawnNumbers []int) Mask {
+	drawnMap := make(map[int]bool)
+	for _, num := range drawnNumbers {
+		drawnMap[num] = true
 	}
-
-	// Check diagonal (top-right to bottom-left)
-	diagComplete = true
-	for i := 0; i < 5; i++ {
-		if !c.Marks[i][4-i] {
-			diagComplete = false
-			break
+	covered := make(Mask, len(c.Grid))
+	for i := range c.Grid {
+		covered[i] = make([]bool, len(c.Grid[i]))
+		for j, num := range c.Grid[i] {
+			covered[i][j] = num == 0 || drawnMap[num]
 		}
 	}
-	if diagComplete {
-		return true
-	}
-
-	return false
+	return covered
 }
 
-// ValidateBingo validates the card against the drawn numbers.
-func (c *Card) ValidateBingo(drawnNumbers []int) bool {
-	// Create a map of drawn numbers for quick lookup
-	drawnMap := make(map[int]bool)
-	for _, num := range drawnNumbers {
-		drawnMap[num] = true
+// ValidateBingo validates the card against the drawn numbers and the win
+// pattern of the game. Every drawn number on the card counts, marked or not;
+// a marked number that was not drawn makes the claim invalid, and so does a
+// pattern of another variant.
+func (c *Card) ValidateBingo(drawnNumbers []int, pattern Pattern) bool {
+	if pattern.VariantName() != c.VariantName() {
+		return false
 	}
+	covered := c.Covered(drawnNumbers)
 
 	// Check if all marked numbers were actually drawn
-	for i := 0; i < 5; i++ {
-		for j := 0; j < 5; j++ {
-			if c.Marks[i][j] {
-				// Ignore center free space cell with 0
-				if c.Grid[i][j] != 0 && !drawnMap[c.Grid[i][j]] {
-					return false // Marked number was not drawn
-				}
+	for i := range c.Marks {
+		for j := range c.Marks[i] {
+			if c.Marks[i][j] && (i >= len(covered) || j >= len(covered[i]) || !covered[i][j]) {
+				return false // Marked number was not drawn
 			}
 		}
 	}
 
-	return c.HasWinningPattern()
+	return pattern.Matches(covered)
 }
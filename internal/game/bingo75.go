@@ -0,0 +1,113 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// bingo75 is 75-ball bingo: 5x5 cards with a free space in the centre and
+// the B-I-N-G-O columns holding 1-15, 16-30, 31-45, 46-60 and 61-75.
+type bingo75 struct{}
+
+func (bingo75) Name() string           { return Variant75 }
+func (bingo75) Numbers() int           { return 75 }
+func (bingo75) Size() (rows, cols int) { return 5, 5 }
+func (bingo75) DefaultPattern() string { return PatternLine }
+
+// CheckMask accepts any mask with a cell besides the free space, which is
+// always covered; every other cell of a 75-ball card has a number.
+func (bingo75) CheckMask(m Mask) error {
+	if m.Cells() == 1 && m[2][2] {
+		return errors.New("mask needs a cell besides the free space")
+	}
+	return nil
+}
+
+func (bingo75) NewCards(n int) []*Card {
+	return uniqueCards(n, func() []*Card { return []*Card{NewCard()} })
+}
+
+func (bingo75) Patterns() []Pattern {
+	return bingo75Patterns
+}
+
+// NewCard creates a 5x5 Bingo card with numbers from traditional Bingo ranges per column
+// and center cell marked as free space (0).
+func NewCard() *Card {
+	card := newGrid(Variant75, 5, 5)
+
+	// Define number ranges per column
+	ranges := [5][2]int{
+		{1, 15},  // B
+		{16, 30}, // I
+		{31, 45}, // N
+		{46, 60}, // G
+		{61, 75}, // O
+	}
+
+	for col := 0; col < 5; col++ {
+		// Generate 5 unique numbers within the column range
+		numRange := ranges[col][1] - ranges[col][0] + 1
+		nums := rand.Perm(numRange)[:5] // first 5 unique numbers from range size
+
+		for row := 0; row < 5; row++ {
+			card.Grid[row][col] = nums[row] + ranges[col][0] // shift by start of range
+		}
+	}
+
+	// Center cell (2,2) is free space: number = 0 and marked
+	card.Grid[2][2] = 0
+	card.Marks[2][2] = true
+
+	return card
+}
+
+// BingoHeaders returns the BINGO column headers.
+func BingoHeaders() [5]string {
+	return [5]string{"B", "I", "N", "G", "O"}
+}
+
+// lineMasks returns every row, column and diagonal of a 5x5 card.
+func lineMasks() []Mask {
+	masks := make([]Mask, 0, 12)
+	for i := 0; i < 5; i++ {
+		row, col := emptyMask(5, 5), emptyMask(5, 5)
+		for j := 0; j < 5; j++ {
+			row[i][j] = true
+			col[j][i] = true
+		}
+		masks = append(masks, row, col)
+	}
+	diag, anti := emptyMask(5, 5), emptyMask(5, 5)
+	for i := 0; i < 5; i++ {
+		diag[i][i] = true
+		anti[i][4-i] = true
+	}
+	return append(masks, diag, anti)
+}
+
+var bingo75Patterns = builtIn(Variant75, []Pattern{
+	{Name: PatternLine, Masks: lineMasks()},
+	{Name: PatternTwoLines, Masks: pairs(lineMasks())},
+	{Name: PatternFourCorners, Masks: []Mask{
+		parseMask("X...X", ".....", ".....", ".....", "X...X"),
+	}},
+	{Name: PatternPostageStamp, Masks: []Mask{
+		parseMask("XX...", "XX...", ".....", ".....", "....."),
+		parseMask("...XX", "...XX", ".....", ".....", "....."),
+		parseMask(".....", ".....", ".....", "XX...", "XX..."),
+		parseMask(".....", ".....", ".....", "...XX", "...XX"),
+	}},
+	{Name: PatternX, Masks: []Mask{
+		parseMask("X...X", ".X.X.", "..X..", ".X.X.", "X...X"),
+	}},
+	{Name: PatternLetterT, Masks: []Mask{
+		parseMask("XXXXX", "..X..", "..X..", "..X..", "..X.."),
+	}},
+	{Name: PatternLetterL, Masks: []Mask{
+		parseMask("X....", "X....", "X....", "X....", "XXXXX"),
+	}},
+	{Name: PatternFullHouse, Masks: []Mask{
+		parseMask("XXXXX", "XXXXX", "XXXXX", "XXXXX", "XXXXX"),
+	}},
+})
@@ -0,0 +1,222 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+)
+
+// bingo90 is 90-ball bingo: 3x9 tickets with 15 numbers, five to a row, dealt
+// in strips of six that between them hold every number from 1 to 90 once.
+// The first column holds 1-9, the last 80-90 and the others the tens between.
+type bingo90 struct{}
+
+const (
+	ticketRows    = 3
+	ticketCols    = 9
+	ticketNumbers = 15
+	rowNumbers    = 5
+	stripTickets  = 6
+)
+
+func (bingo90) Name() string           { return Variant90 }
+func (bingo90) Numbers() int           { return 90 }
+func (bingo90) Size() (rows, cols int) { return ticketRows, ticketCols }
+func (bingo90) DefaultPattern() string { return PatternFullHouse }
+
+// NewCards deals whole strips, so it returns n rounded up to a multiple of six.
+func (bingo90) NewCards(n int) []*Card {
+	strip := 0
+	return uniqueCards(n, func() []*Card {
+		strip++
+		return NewStrip(strip)
+	})
+}
+
+// CheckMask only accepts whole rows. The blank cells of a ticket count as
+// covered and differ from ticket to ticket, so a mask of single cells could
+// be completed by blanks before any number is drawn.
+func (bingo90) CheckMask(m Mask) error {
+	for _, row := range m {
+		for _, cell := range row {
+			if cell != row[0] {
+				return errors.New("90-ball masks must cover whole rows")
+			}
+		}
+	}
+	return nil
+}
+
+func (bingo90) Patterns() []Pattern {
+	return bingo90Patterns
+}
+
+// columnNumbers returns the numbers of a 90-ball column.
+func columnNumbers(col int) []int {
+	lo, hi := col*10, col*10+9
+	if col == 0 {
+		lo = 1
+	}
+	if col == ticketCols-1 {
+		hi = 90
+	}
+	nums := make([]int, 0, hi-lo+1)
+	for n := lo; n <= hi; n++ {
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+// NewStrip creates a strip of six 90-ball tickets numbered strip. Every
+// column of every ticket holds one to three numbers, in ascending order from
+// top to bottom.
+func NewStrip(strip int) []*Card {
+	counts := stripCounts()
+	tickets := make([]*Card, stripTickets)
+	for t := range tickets {
+		tickets[t] = newGrid(Variant90, ticketRows, ticketCols)
+		tickets[t].Strip = strip
+	}
+
+	for col := 0; col < ticketCols; col++ {
+		nums := columnNumbers(col)
+		rand.Shuffle(len(nums), func(i, j int) { nums[i], nums[j] = nums[j], nums[i] })
+		for t := range tickets {
+			k := counts[t][col]
+			dealt := nums[:k]
+			nums = nums[k:]
+			sort.Ints(dealt)
+			tickets[t].placeColumn(col, dealt)
+		}
+	}
+	for t := range tickets {
+		tickets[t].packRows(counts[t])
+	}
+	return tickets
+}
+
+// stripCounts decides how many numbers each ticket of a strip has in each
+// column. Every ticket starts with one number per column and the rest of
+// each column is handed out to the tickets that still need the most, which
+// can dead-end, so it starts over until every ticket has 15.
+func stripCounts() [stripTickets][ticketCols]int {
+	for {
+		var counts [stripTickets][ticketCols]int
+		var totals [stripTickets]int
+		var extra []int
+		for col := 0; col < ticketCols; col++ {
+			for t := range counts {
+				counts[t][col] = 1
+			}
+			for i := stripTickets; i < len(columnNumbers(col)); i++ {
+				extra = append(extra, col)
+			}
+		}
+		for t := range totals {
+			totals[t] = ticketCols
+		}
+		rand.Shuffle(len(extra), func(i, j int) { extra[i], extra[j] = extra[j], extra[i] })
+
+		ok := true
+		for _, col := range extra {
+			best := -1
+			for _, t := range rand.Perm(stripTickets) {
+				if counts[t][col] == ticketRows || totals[t] == ticketNumbers {
+					continue
+				}
+				if best < 0 || totals[t] < totals[best] {
+					best = t
+				}
+			}
+			if best < 0 {
+				ok = false
+				break
+			}
+			counts[best][col]++
+			totals[best]++
+		}
+		if ok {
+			return counts
+		}
+	}
+}
+
+// placeColumn writes a column's numbers to the top of the ticket, in order;
+// packRows then moves them to their rows.
+func (c *Card) placeColumn(col int, nums []int) {
+	for row, n := range nums {
+		c.Grid[row][col] = n
+	}
+}
+
+// packRows spreads the numbers of each column over the rows so that every
+// row has five, keeping each column in ascending order. Columns with the
+// most numbers are placed first, each in the rows that still need the most;
+// on a dead end it starts over.
+func (c *Card) packRows(counts [ticketCols]int) {
+	order := make([]int, ticketCols)
+	for i := range order {
+		order[i] = i
+	}
+	for {
+		rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+
+		var need [ticketRows]int
+		for i := range need {
+			need[i] = rowNumbers
+		}
+		var layout [ticketCols][]int
+		ok := true
+		for _, col := range order {
+			rows := rand.Perm(ticketRows)
+			sort.SliceStable(rows, func(i, j int) bool { return need[rows[i]] > need[rows[j]] })
+			rows = rows[:counts[col]]
+			for _, r := range rows {
+				if need[r] == 0 {
+					ok = false
+				}
+				need[r]--
+			}
+			sort.Ints(rows)
+			layout[col] = rows
+		}
+		if !ok {
+			continue
+		}
+
+		for col, rows := range layout {
+			nums := make([]int, 0, len(rows))
+			for row := 0; row < ticketRows; row++ {
+				if c.Grid[row][col] != 0 {
+					nums = append(nums, c.Grid[row][col])
+				}
+				c.Grid[row][col] = 0
+			}
+			for i, r := range rows {
+				c.Grid[r][col] = nums[i]
+			}
+		}
+		return
+	}
+}
+
+// rowMasks returns a mask per row of a 90-ball ticket.
+func rowMasks() []Mask {
+	masks := make([]Mask, ticketRows)
+	for i := range masks {
+		masks[i] = emptyMask(ticketRows, ticketCols)
+		for j := 0; j < ticketCols; j++ {
+			masks[i][i][j] = true
+		}
+	}
+	return masks
+}
+
+var bingo90Patterns = builtIn(Variant90, []Pattern{
+	{Name: PatternOneLine, Masks: rowMasks()},
+	{Name: PatternTwoLines, Masks: pairs(rowMasks())},
+	{Name: PatternFullHouse, Masks: []Mask{
+		parseMask("XXXXXXXXX", "XXXXXXXXX", "XXXXXXXXX"),
+	}},
+})
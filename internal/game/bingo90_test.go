@@ -0,0 +1,88 @@
+package game
+
+import "testing"
+
+func TestNewStrip(t *testing.T) {
+	for run := 0; run < 500; run++ {
+		strip := NewStrip(run + 1)
+		if len(strip) != stripTickets {
+			t.Fatalf("strip has %d tickets, want %d", len(strip), stripTickets)
+		}
+		seen := make(map[int]int)
+		for ti, ticket := range strip {
+			if ticket.Variant != Variant90 || ticket.Strip != run+1 {
+				t.Fatalf("ticket %d is %s strip %d, want %s strip %d", ti, ticket.Variant, ticket.Strip, Variant90, run+1)
+			}
+			if len(ticket.Grid) != ticketRows {
+				t.Fatalf("ticket %d has %d rows, want %d", ti, len(ticket.Grid), ticketRows)
+			}
+			total := 0
+			for r, row := range ticket.Grid {
+				if len(row) != ticketCols {
+					t.Fatalf("ticket %d row %d has %d cells, want %d", ti, r, len(row), ticketCols)
+				}
+				n := 0
+				for _, num := range row {
+					if num != 0 {
+						n++
+					}
+				}
+				if n != rowNumbers {
+					t.Fatalf("ticket %d row %d has %d numbers, want %d: %v", ti, r, n, rowNumbers, ticket.Grid)
+				}
+				total += n
+			}
+			if total != ticketNumbers {
+				t.Fatalf("ticket %d has %d numbers, want %d", ti, total, ticketNumbers)
+			}
+			for col := 0; col < ticketCols; col++ {
+				nums := columnNumbers(col)
+				lo, hi := nums[0], nums[len(nums)-1]
+				prev, n := 0, 0
+				for r := 0; r < ticketRows; r++ {
+					num := ticket.Grid[r][col]
+					if num == 0 {
+						continue
+					}
+					if num < lo || num > hi {
+						t.Fatalf("ticket %d column %d holds %d, want %d-%d", ti, col, num, lo, hi)
+					}
+					if num <= prev {
+						t.Fatalf("ticket %d column %d is not ascending: %v", ti, col, ticket.Grid)
+					}
+					prev = num
+					n++
+					seen[num]++
+				}
+				if n == 0 {
+					t.Fatalf("ticket %d column %d is empty", ti, col)
+				}
+			}
+		}
+		for num := 1; num <= 90; num++ {
+			if seen[num] != 1 {
+				t.Fatalf("strip holds %d %d times, want once", num, seen[num])
+			}
+		}
+		if len(seen) != 90 {
+			t.Fatalf("strip holds %d distinct numbers, want 90", len(seen))
+		}
+	}
+}
+
+func TestColumnNumbers(t *testing.T) {
+	tests := []struct {
+		col, lo, hi int
+	}{
+		{col: 0, lo: 1, hi: 9},
+		{col: 1, lo: 10, hi: 19},
+		{col: 4, lo: 40, hi: 49},
+		{col: 8, lo: 80, hi: 90},
+	}
+	for _, tt := range tests {
+		nums := columnNumbers(tt.col)
+		if nums[0] != tt.lo || nums[len(nums)-1] != tt.hi || len(nums) != tt.hi-tt.lo+1 {
+			t.Errorf("columnNumbers(%d) = %d-%d (%d), want %d-%d", tt.col, nums[0], nums[len(nums)-1], len(nums), tt.lo, tt.hi)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Built-in win patterns. 75-ball has all but one_line; 90-ball has
+// one_line, two_lines and full_house.
+const (
+	PatternLine         = "line"
+	PatternOneLine      = "one_line"
+	PatternTwoLines     = "two_lines"
+	PatternFourCorners  = "four_corners"
+	PatternPostageStamp = "postage_stamp"
+	PatternX            = "x"
+	PatternLetterT      = "letter_t"
+	PatternLetterL      = "letter_l"
+	PatternFullHouse    = "full_house"
+)
+
+// Mask marks the cells of a card that have to be covered, row by row.
+type Mask [][]bool
+
+// Pattern is a named win condition of a variant. A card wins when it covers
+// every cell of any one of the pattern's masks.
+type Pattern struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant"`
+	Masks   []Mask `json:"masks"`
+	BuiltIn bool   `json:"built_in"`
+}
+
+var patternName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// maxMasks caps how many masks a custom pattern may have
+const maxMasks = 64
+
+// parseMask builds a mask from rows of characters, X for a cell that has to
+// be covered.
+func parseMask(rows ...string) Mask {
+	m := make(Mask, len(rows))
+	for i, row := range rows {
+		m[i] = make([]bool, len(row))
+		for j, ch := range row {
+			m[i][j] = ch == 'X'
+		}
+	}
+	return m
+}
+
+// emptyMask returns a mask of the given size with no cells.
+func emptyMask(rows, cols int) Mask {
+	m := make(Mask, rows)
+	for i := range m {
+		m[i] = make([]bool, cols)
+	}
+	return m
+}
+
+// union returns a mask of the cells of both masks, which have the same size.
+func union(a, b Mask) Mask {
+	m := emptyMask(len(a), len(a[0]))
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = a[i][j] || b[i][j]
+		}
+	}
+	return m
+}
+
+// pairs returns the union of every pair of masks.
+func pairs(masks []Mask) []Mask {
+	out := make([]Mask, 0, len(masks)*(len(masks)-1)/2)
+	for a := 0; a < len(masks); a++ {
+		for b := a + 1; b < len(masks); b++ {
+			out = append(out, union(masks[a], masks[b]))
+		}
+	}
+	return out
+}
+
+// builtIn marks patterns as the built-in ones of a variant.
+func builtIn(variant string, patterns []Pattern) []Pattern {
+	for i := range patterns {
+		patterns[i].Variant = variant
+		patterns[i].BuiltIn = true
+	}
+	return patterns
+}
+
+// BuiltInPatterns returns the built-in patterns of every variant.
+func BuiltInPatterns() []Pattern {
+	var patterns []Pattern
+	for _, v := range variants {
+		patterns = append(patterns, v.Patterns()...)
+	}
+	return patterns
+}
+
+// BuiltInPattern returns the built-in pattern of a variant with the given name.
+func BuiltInPattern(variant, name string) (Pattern, bool) {
+	v, ok := LookupVariant(variant)
+	if !ok {
+		return Pattern{}, false
+	}
+	for _, p := range v.Patterns() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Pattern{}, false
+}
+
+// LinePattern returns the classic 75-ball pattern: any row, column or diagonal.
+func LinePattern() Pattern {
+	p, _ := BuiltInPattern(Variant75, PatternLine)
+	return p
+}
+
+// NewPattern checks a custom pattern for a variant and returns it. Names are
+// lower case letters, digits and underscores, and may not shadow a built-in
+// pattern of the variant. Masks have the size of the variant's cards and
+// pass its CheckMask.
+func NewPattern(variant, name string, masks []Mask) (Pattern, error) {
+	v, ok := LookupVariant(variant)
+	if !ok {
+		return Pattern{}, fmt.Errorf("unknown variant %q", variant)
+	}
+	if !patternName.MatchString(name) {
+		return Pattern{}, errors.New("name must be 1 to 32 lower case letters, digits or underscores")
+	}
+	if _, ok := BuiltInPattern(v.Name(), name); ok {
+		return Pattern{}, fmt.Errorf("%s is a built-in pattern", name)
+	}
+	if len(masks) == 0 || len(masks) > maxMasks {
+		return Pattern{}, fmt.Errorf("a pattern needs 1 to %d masks", maxMasks)
+	}
+	rows, cols := v.Size()
+	for i, m := range masks {
+		if len(m) != rows {
+			return Pattern{}, fmt.Errorf("mask %d must have %d rows of %d cells", i+1, rows, cols)
+		}
+		for _, row := range m {
+			if len(row) != cols {
+				return Pattern{}, fmt.Errorf("mask %d must have %d rows of %d cells", i+1, rows, cols)
+			}
+		}
+		if m.Cells() == 0 {
+			return Pattern{}, fmt.Errorf("mask %d has no cells", i+1)
+		}
+		if err := v.CheckMask(m); err != nil {
+			return Pattern{}, fmt.Errorf("mask %d: %w", i+1, err)
+		}
+	}
+	return Pattern{Name: name, Variant: v.Name(), Masks: masks}, nil
+}
+
+// VariantName returns the pattern's variant; patterns from before variants existed are 75-ball.
+func (p Pattern) VariantName() string {
+	if p.Variant == "" {
+		return Variant75
+	}
+	return p.Variant
+}
+
+// Cells counts the cells of the mask.
+func (m Mask) Cells() int {
+	n := 0
+	for _, row := range m {
+		for _, cell := range row {
+			if cell {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Matches reports whether the covered cells include every cell of one of the
+// pattern's masks.
+func (p Pattern) Matches(covered Mask) bool {
+	for _, m := range p.Masks {
+		if covers(covered, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func covers(covered, m Mask) bool {
+	for i, row := range m {
+		for j, cell := range row {
+			if cell && (i >= len(covered) || j >= len(covered[i]) || !covered[i][j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
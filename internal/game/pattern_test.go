@@ -0,0 +1,78 @@
+package game
+
+import "testing"
+
+func TestNewPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant string
+		pattern string
+		masks   []Mask
+		wantErr bool
+	}{
+		{name: "75-ball cells", variant: Variant75, pattern: "corners_and_centre",
+			masks: []Mask{parseMask("X...X", ".....", "..X..", ".....", "X...X")}},
+		{name: "90-ball rows", variant: Variant90, pattern: "top_and_bottom",
+			masks: []Mask{parseMask("XXXXXXXXX", ".........", "XXXXXXXXX")}},
+		{name: "90-ball cells", variant: Variant90, pattern: "corners",
+			masks: []Mask{parseMask("X.......X", ".........", "X.......X")}, wantErr: true},
+		{name: "90-ball part row", variant: Variant90, pattern: "half_row",
+			masks: []Mask{parseMask("XXXXX....", ".........", ".........")}, wantErr: true},
+		{name: "unknown variant", variant: "80_ball", pattern: "any",
+			masks: []Mask{parseMask("X")}, wantErr: true},
+		{name: "bad name", variant: Variant75, pattern: "Bad Name",
+			masks: []Mask{parseMask("X....", ".....", ".....", ".....", ".....")}, wantErr: true},
+		{name: "built-in name", variant: Variant90, pattern: PatternFullHouse,
+			masks: []Mask{parseMask("XXXXXXXXX", ".........", ".........")}, wantErr: true},
+		{name: "wrong size", variant: Variant90, pattern: "small",
+			masks: []Mask{parseMask("XXXXX", ".....", ".....", ".....", ".....")}, wantErr: true},
+		{name: "free space only", variant: Variant75, pattern: "free",
+			masks: []Mask{parseMask(".....", ".....", "..X..", ".....", ".....")}, wantErr: true},
+		{name: "free space and a cell", variant: Variant75, pattern: "free_and_corner",
+			masks: []Mask{parseMask("X....", ".....", "..X..", ".....", ".....")}},
+		{name: "empty mask", variant: Variant75, pattern: "nothing",
+			masks: []Mask{emptyMask(5, 5)}, wantErr: true},
+		{name: "no masks", variant: Variant75, pattern: "none", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := NewPattern(tt.variant, tt.pattern, tt.masks)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: NewPattern succeeded, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: NewPattern error: %v", tt.name, err)
+			continue
+		}
+		if p.Name != tt.pattern || p.Variant != tt.variant || p.BuiltIn {
+			t.Errorf("%s: NewPattern = %+v", tt.name, p)
+		}
+	}
+}
+
+func TestPatternMatchesOnlyNumbers(t *testing.T) {
+	ticket := NewStrip(1)[0]
+	one, _ := BuiltInPattern(Variant90, PatternOneLine)
+	full, _ := BuiltInPattern(Variant90, PatternFullHouse)
+	if one.Matches(ticket.Covered(nil)) {
+		t.Fatal("one_line matches a ticket with nothing drawn")
+	}
+
+	var row []int
+	for _, num := range ticket.Grid[1] {
+		if num != 0 {
+			row = append(row, num)
+		}
+	}
+	if !one.Matches(ticket.Covered(row)) {
+		t.Error("one_line does not match a ticket with a row drawn")
+	}
+	if one.Matches(ticket.Covered(row[1:])) {
+		t.Error("one_line matches a ticket with a row but one drawn")
+	}
+	if full.Matches(ticket.Covered(row)) {
+		t.Error("full_house matches a ticket with one row drawn")
+	}
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+)
+
+// Card variants
+const (
+	Variant75 = "75_ball"
+	Variant90 = "90_ball"
+)
+
+// Variant is a kind of bingo: the numbers that are drawn, the cards that are
+// played and the patterns that win.
+type Variant interface {
+	// Name is how rooms, cards and patterns refer to the variant.
+	Name() string
+	// Numbers is how many numbers there are; they are drawn from 1 to Numbers.
+	Numbers() int
+	// Size is the number of rows and columns of a card.
+	Size() (rows, cols int)
+	// NewCards returns at least n unique cards.
+	NewCards(n int) []*Card
+	// Patterns returns the built-in win patterns, the single line first.
+	Patterns() []Pattern
+	// DefaultPattern is the pattern of a room that does not pick one.
+	DefaultPattern() string
+	// CheckMask reports why a mask of the right size can't be used in a
+	// custom pattern, or nil if it can.
+	CheckMask(m Mask) error
+}
+
+var variants = []Variant{bingo75{}, bingo90{}}
+
+// Variants returns every variant, 75-ball first.
+func Variants() []Variant {
+	list := make([]Variant, len(variants))
+	copy(list, variants)
+	return list
+}
+
+// LookupVariant returns the variant with the given name. Cards and patterns
+// from before variants existed have no name and are 75-ball.
+func LookupVariant(name string) (Variant, bool) {
+	if name == "" {
+		name = Variant75
+	}
+	for _, v := range variants {
+		if v.Name() == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// GenerateCallouts creates a shuffled list of the variant's numbers.
+func GenerateCallouts(v Variant) []int {
+	nums := make([]int, v.Numbers())
+	for i := range nums {
+		nums[i] = i + 1
+	}
+	rand.Shuffle(len(nums), func(i, j int) {
+		nums[i], nums[j] = nums[j], nums[i]
+	})
+	return nums
+}
+
+// GenerateAvailableCards creates the cards players pick from in a room of
+// the variant: 100, or more where the variant deals cards in sets.
+func GenerateAvailableCards(v Variant) []*Card {
+	return v.NewCards(100)
+}
+
+// uniqueCards collects cards from next until it has n unique ones, skipping
+// any card already seen. next may return several cards at once.
+func uniqueCards(n int, next func() []*Card) []*Card {
+	cards := make([]*Card, 0, n)
+	cardSet := make(map[string]bool)
+	for len(cards) < n {
+		batch := next()
+		keys := make([]string, 0, len(batch))
+		duplicate := false
+		for _, card := range batch {
+			jsonBytes, err := json.Marshal(card.Grid)
+			if err != nil || cardSet[string(jsonBytes)] {
+				duplicate = true // skip the whole batch, so sets stay whole
+				break
+			}
+			keys = append(keys, string(jsonBytes))
+		}
+		if duplicate {
+			continue
+		}
+		for i, card := range batch {
+			cardSet[keys[i]] = true
+			cards = append(cards, card)
+		}
+	}
+	return cards
+}
@@ -0,0 +1,148 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"rockbingo/internal/db"
+	"time"
+)
+
+// PayoutDispatcher sends approved withdrawals through a payout provider. A
+// send the provider does not take is retried with exponential backoff until
+// MaxAttempts, after which the withdrawal fails and its funds are returned.
+// Payouts still processing after StaleAfter are reconciled by asking the
+// provider, in case its callback was lost.
+type PayoutDispatcher struct {
+	Store       *db.WithdrawalStore
+	Provider    PayoutProvider
+	Interval    time.Duration
+	MaxAttempts int
+	StaleAfter  time.Duration
+}
+
+// NewPayoutDispatcher returns a dispatcher with the default schedule.
+func NewPayoutDispatcher(store *db.WithdrawalStore, provider PayoutProvider) *PayoutDispatcher {
+	return &PayoutDispatcher{
+		Store:       store,
+		Provider:    provider,
+		Interval:    30 * time.Second,
+		MaxAttempts: 5,
+		StaleAfter:  30 * time.Minute,
+	}
+}
+
+// Start runs the dispatcher in a background goroutine.
+func (d *PayoutDispatcher) Start() {
+	go func() {
+		for {
+			if err := d.RunOnce(context.Background()); err != nil {
+				log.Printf("[PayoutDispatcher] %v", err)
+			}
+			time.Sleep(d.Interval)
+		}
+	}()
+}
+
+// RunOnce sends every due payout, then reconciles stale ones.
+func (d *PayoutDispatcher) RunOnce(ctx context.Context) error {
+	due, err := d.Store.DuePayouts(ctx, 50)
+	if err != nil {
+		return err
+	}
+	for _, req := range due {
+		d.send(ctx, req.ID)
+	}
+
+	stale, err := d.Store.StalePayouts(ctx, time.Now().Add(-d.StaleAfter))
+	if err != nil {
+		return err
+	}
+	for _, req := range stale {
+		d.reconcile(ctx, &req)
+	}
+	return nil
+}
+
+// send hands one approved withdrawal to the provider
+func (d *PayoutDispatcher) send(ctx context.Context, id int64) {
+	req, err := d.Store.StartPayout(ctx, id, d.Provider.Name())
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("[PayoutDispatcher] Start payout of withdrawal %d: %v", id, err)
+		return
+	}
+	if req.PayoutMethodID == nil {
+		d.retry(ctx, req, errors.New("withdrawal has no payout method"), false)
+		return
+	}
+	method, err := d.Store.GetPayoutMethod(ctx, *req.PayoutMethodID)
+	if err != nil {
+		d.retry(ctx, req, err, true)
+		return
+	}
+
+	reference := db.PayoutReference(req.ID)
+	result, err := d.Provider.SendPayout(ctx, Payout{
+		Reference:   reference,
+		Amount:      req.Amount,
+		Destination: method.Destination(),
+	})
+	if err != nil {
+		d.retry(ctx, req, err, true)
+		return
+	}
+	if err := d.Store.SetPayoutRef(ctx, req.ID, result.ProviderRef); err != nil {
+		log.Printf("[PayoutDispatcher] Record payout of withdrawal %d: %v", req.ID, err)
+	}
+	log.Printf("[PayoutDispatcher] Withdrawal %d sent as %s (%s)", req.ID, result.ProviderRef, result.Status)
+	if result.Status == StatusCompleted || result.Status == StatusFailed {
+		d.complete(ctx, reference, result.ProviderRef, result.Status)
+	}
+}
+
+// reconcile asks the provider about a payout whose callback has not arrived
+func (d *PayoutDispatcher) reconcile(ctx context.Context, req *db.WithdrawalRequest) {
+	reference := db.PayoutReference(req.ID)
+	status, err := d.Provider.PayoutStatus(ctx, reference)
+	if errors.Is(err, ErrUnknownPayout) {
+		// The send never reached the provider; send it again
+		d.retry(ctx, req, err, true)
+		return
+	}
+	if err != nil {
+		log.Printf("[PayoutDispatcher] Status of withdrawal %d: %v", req.ID, err)
+		return
+	}
+	if status == StatusCompleted || status == StatusFailed {
+		log.Printf("[PayoutDispatcher] Reconciled withdrawal %d as %s", req.ID, status)
+		d.complete(ctx, reference, "", status)
+	}
+}
+
+func (d *PayoutDispatcher) complete(ctx context.Context, reference, providerRef string, status Status) {
+	if _, err := d.Store.CompletePayout(ctx, reference, providerRef, status == StatusCompleted, nil); err != nil {
+		log.Printf("[PayoutDispatcher] Complete payout %s: %v", reference, err)
+	}
+}
+
+// retry schedules another attempt, or fails the withdrawal once attempts
+// run out or the error is not retryable
+func (d *PayoutDispatcher) retry(ctx context.Context, req *db.WithdrawalRequest, cause error, retryable bool) {
+	var retryAt *time.Time
+	if retryable && req.PayoutAttempts < d.MaxAttempts {
+		backoff := time.Minute << (req.PayoutAttempts - 1)
+		if backoff > time.Hour {
+			backoff = time.Hour
+		}
+		at := time.Now().Add(backoff)
+		retryAt = &at
+	}
+	log.Printf("[PayoutDispatcher] Payout of withdrawal %d failed (attempt %d): %v", req.ID, req.PayoutAttempts, cause)
+	if err := d.Store.RetryPayout(ctx, req.ID, cause.Error(), retryAt); err != nil {
+		log.Printf("[PayoutDispatcher] Retry payout of withdrawal %d: %v", req.ID, err)
+	}
+}
@@ -0,0 +1,161 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"rockbingo/internal/db"
+	"strings"
+	"sync"
+)
+
+const (
+	FakeProviderName = "fake"
+	// FakeSignatureHeader carries the hex HMAC-SHA256 of a fake webhook body.
+	FakeSignatureHeader = "X-Fake-Signature"
+)
+
+// ErrUnknownCheckout is returned for a payment session the provider did not open.
+var ErrUnknownCheckout = errors.New("unknown checkout session")
+
+// FakeProvider is an in-process payment provider for development and testing. Its
+// checkout page is served by the API itself (see Pay), and paying there
+// produces the same signed webhook a real provider would send, so deposits
+// can be tested end to end without a network.
+type FakeProvider struct {
+	secret  []byte
+	baseURL string
+
+	mu       sync.Mutex
+	sessions map[string]*fakeSession
+}
+
+type fakeSession struct {
+	reference string
+	amount    db.Money
+	status    Status
+}
+
+// fakeWebhook is the body of a fake webhook callback.
+type fakeWebhook struct {
+	Reference   string   `json:"reference"`
+	ProviderRef string   `json:"provider_ref"`
+	Status      Status   `json:"status"`
+	Amount      db.Money `json:"amount"`
+	Currency    string   `json:"currency"`
+}
+
+// NewFakeProvider returns a fake provider signing webhooks with secret, or
+// with a random secret if it is empty.
+func NewFakeProvider(secret, baseURL string) *FakeProvider {
+	return &FakeProvider{
+		secret:   fakeSecret(secret),
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		sessions: make(map[string]*fakeSession),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakeProvider) CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	ref := "fake_" + hex.EncodeToString(b)
+
+	p.mu.Lock()
+	p.sessions[ref] = &fakeSession{reference: req.Reference, amount: req.Amount, status: StatusPending}
+	p.mu.Unlock()
+
+	return &Checkout{ProviderRef: ref, URL: p.baseURL + "/api/payments/fake/checkout/" + ref}, nil
+}
+
+func (p *FakeProvider) VerifyWebhook(header http.Header, body []byte) (*Event, error) {
+	return verifyFakeEvent(p.secret, header, body)
+}
+
+func (p *FakeProvider) Status(ctx context.Context, providerRef string) (Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	session, ok := p.sessions[providerRef]
+	if !ok {
+		return "", ErrUnknownCheckout
+	}
+	return session.status, nil
+}
+
+// Pay settles a checkout session with status, as a player paying (or
+// abandoning) the checkout page would, and returns the signed webhook the
+// provider sends about it.
+func (p *FakeProvider) Pay(providerRef string, status Status) (http.Header, []byte, error) {
+	p.mu.Lock()
+	session, ok := p.sessions[providerRef]
+	if ok {
+		session.status = status
+	}
+	p.mu.Unlock()
+	if !ok {
+		return nil, nil, ErrUnknownCheckout
+	}
+
+	body, err := json.Marshal(fakeWebhook{
+		Reference:   session.reference,
+		ProviderRef: providerRef,
+		Status:      status,
+		Amount:      session.amount,
+		Currency:    string(session.amount.Cur()),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return signedFakeHeader(p.secret, body), body, nil
+}
+
+// fakeSecret returns the signing key for a fake provider, random if secret is empty
+func fakeSecret(secret string) []byte {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return key
+}
+
+func signFake(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedFakeHeader returns the headers of a fake callback carrying body
+func signedFakeHeader(secret, body []byte) http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(FakeSignatureHeader, signFake(secret, body))
+	return header
+}
+
+// verifyFakeEvent checks the signature of a fake callback and decodes it
+func verifyFakeEvent(secret []byte, header http.Header, body []byte) (*Event, error) {
+	if !hmac.Equal([]byte(signFake(secret, body)), []byte(header.Get(FakeSignatureHeader))) {
+		return nil, ErrInvalidSignature
+	}
+	var wh fakeWebhook
+	if err := json.Unmarshal(body, &wh); err != nil {
+		return nil, err
+	}
+	return &Event{
+		Reference:   wh.Reference,
+		ProviderRef: wh.ProviderRef,
+		Status:      wh.Status,
+		Amount:      db.NewMoney(wh.Amount.Minor, db.Currency(wh.Currency)),
+	}, nil
+}
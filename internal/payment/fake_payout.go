@@ -0,0 +1,92 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"rockbingo/internal/db"
+	"sync"
+)
+
+// FakePayoutProvider is an in-process payout provider for development and
+// testing. Payouts stay pending until Settle is called for them, which
+// produces the signed callback a real provider would send.
+type FakePayoutProvider struct {
+	secret []byte
+
+	mu      sync.Mutex
+	payouts map[string]*fakePayout
+}
+
+type fakePayout struct {
+	providerRef string
+	amount      db.Money
+	status      Status
+}
+
+// NewFakePayoutProvider returns a fake payout provider signing callbacks with
+// secret, or with a random secret if it is empty.
+func NewFakePayoutProvider(secret string) *FakePayoutProvider {
+	return &FakePayoutProvider{secret: fakeSecret(secret), payouts: make(map[string]*fakePayout)}
+}
+
+func (p *FakePayoutProvider) Name() string {
+	return FakeProviderName
+}
+
+func (p *FakePayoutProvider) SendPayout(ctx context.Context, payout Payout) (*PayoutResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.payouts[payout.Reference]; ok {
+		return &PayoutResult{ProviderRef: existing.providerRef, Status: existing.status}, nil
+	}
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	fp := &fakePayout{providerRef: "fakepo_" + hex.EncodeToString(b), amount: payout.Amount, status: StatusPending}
+	p.payouts[payout.Reference] = fp
+	return &PayoutResult{ProviderRef: fp.providerRef, Status: fp.status}, nil
+}
+
+func (p *FakePayoutProvider) VerifyCallback(header http.Header, body []byte) (*Event, error) {
+	return verifyFakeEvent(p.secret, header, body)
+}
+
+func (p *FakePayoutProvider) PayoutStatus(ctx context.Context, reference string) (Status, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fp, ok := p.payouts[reference]
+	if !ok {
+		return "", ErrUnknownPayout
+	}
+	return fp.status, nil
+}
+
+// Settle completes or fails the payout under reference, as the provider's
+// network eventually would, and returns the signed callback it sends.
+func (p *FakePayoutProvider) Settle(reference string, status Status) (http.Header, []byte, error) {
+	p.mu.Lock()
+	fp, ok := p.payouts[reference]
+	if ok {
+		fp.status = status
+	}
+	p.mu.Unlock()
+	if !ok {
+		return nil, nil, ErrUnknownPayout
+	}
+
+	body, err := json.Marshal(fakeWebhook{
+		Reference:   reference,
+		ProviderRef: fp.providerRef,
+		Status:      status,
+		Amount:      fp.amount,
+		Currency:    string(fp.amount.Cur()),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return signedFakeHeader(p.secret, body), body, nil
+}
@@ -0,0 +1,81 @@
+// Package payment abstracts the payment providers players pay deposits
+// through. A deposit is paid on the provider's checkout page and is only
+// credited once the provider confirms it with a signed webhook.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"rockbingo/internal/db"
+)
+
+// Status of a payment as reported by the provider.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+var (
+	// ErrInvalidSignature is returned for a webhook that was not signed by the provider.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrFakeNotAllowed is returned when the fake providers are asked for
+	// without opting in to them; they pay out on request.
+	ErrFakeNotAllowed = errors.New("the fake provider is for development only; set ALLOW_FAKE_PAYMENTS=true to use it")
+)
+
+// CheckoutRequest asks a provider to collect Amount for the deposit Reference.
+type CheckoutRequest struct {
+	Reference   string
+	Amount      db.Money
+	Description string
+}
+
+// Checkout is a payment session opened with a provider. The player pays on
+// the page at URL.
+type Checkout struct {
+	ProviderRef string
+	URL         string
+}
+
+// Event is a verified webhook callback about a payment.
+type Event struct {
+	Reference   string
+	ProviderRef string
+	Status      Status
+	Amount      db.Money
+}
+
+// PaymentProvider is a payment gateway deposits are collected through.
+type PaymentProvider interface {
+	// Name identifies the provider in webhook routes and stored deposits.
+	Name() string
+	// CreateCheckout opens a payment session for a deposit.
+	CreateCheckout(ctx context.Context, req CheckoutRequest) (*Checkout, error)
+	// VerifyWebhook authenticates a webhook callback and decodes its event.
+	// It returns ErrInvalidSignature if the request was not signed by the provider.
+	VerifyWebhook(header http.Header, body []byte) (*Event, error)
+	// Status queries the provider for the status of a payment session.
+	Status(ctx context.Context, providerRef string) (Status, error)
+}
+
+// NewProvider returns the provider called name. secret authenticates its
+// webhooks and baseURL is the public URL of the API. The fake provider
+// credits any deposit whose checkout page is opened, so it is only returned
+// when allowFake is set.
+func NewProvider(name, secret, baseURL string, allowFake bool) (PaymentProvider, error) {
+	switch name {
+	case "":
+		return nil, errors.New("no payment provider configured")
+	case FakeProviderName:
+		if !allowFake {
+			return nil, ErrFakeNotAllowed
+		}
+		return NewFakeProvider(secret, baseURL), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", name)
+}
@@ -0,0 +1,59 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"rockbingo/internal/db"
+)
+
+// ErrUnknownPayout is returned when a provider has no payout under a reference.
+var ErrUnknownPayout = errors.New("unknown payout")
+
+// Payout asks a provider to send Amount to Destination. Reference
+// identifies the withdrawal; see db.PayoutReference.
+type Payout struct {
+	Reference   string
+	Amount      db.Money
+	Destination db.PayoutDestination
+}
+
+// PayoutResult is a provider's answer to a payout it accepted. Status is
+// usually pending, the outcome being reported later through a callback.
+type PayoutResult struct {
+	ProviderRef string
+	Status      Status
+}
+
+// PayoutProvider sends approved withdrawals to players' mobile wallets or
+// bank accounts.
+type PayoutProvider interface {
+	// Name identifies the provider in callback routes and stored withdrawals.
+	Name() string
+	// SendPayout hands a payout to the provider. It must be idempotent on
+	// the payout reference: sending a reference again never pays twice.
+	SendPayout(ctx context.Context, p Payout) (*PayoutResult, error)
+	// VerifyCallback authenticates a payout callback and decodes its event.
+	// It returns ErrInvalidSignature if the request was not signed by the provider.
+	VerifyCallback(header http.Header, body []byte) (*Event, error)
+	// PayoutStatus queries the provider for the payout under reference. It
+	// returns ErrUnknownPayout if the provider never received it.
+	PayoutStatus(ctx context.Context, reference string) (Status, error)
+}
+
+// NewPayoutProvider returns the payout provider called name. secret
+// authenticates its callbacks. The fake provider is only returned when
+// allowFake is set.
+func NewPayoutProvider(name, secret string, allowFake bool) (PayoutProvider, error) {
+	switch name {
+	case "":
+		return nil, errors.New("no payout provider configured")
+	case FakeProviderName:
+		if !allowFake {
+			return nil, ErrFakeNotAllowed
+		}
+		return NewFakePayoutProvider(secret), nil
+	}
+	return nil, fmt.Errorf("unknown payout provider %q", name)
+}
@@ -0,0 +1,79 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"rockbingo/internal/db"
+	"time"
+)
+
+// Job periodically checks every wallet against the ledger and every room
+// escrow against the stakes it holds. Discrepancies are recorded for an
+// operator to resolve; newly found ones are logged and passed to Alert.
+// With Freeze, the wallets involved are frozen until they are resolved.
+type Job struct {
+	Store    *db.LedgerStore
+	Interval time.Duration
+	Freeze   bool
+	Alert    func(text string)
+}
+
+// NewJob returns a job with the default schedule that only reports.
+func NewJob(store *db.LedgerStore) *Job {
+	return &Job{
+		Store:    store,
+		Interval: 15 * time.Minute,
+	}
+}
+
+// Start runs the job in a background goroutine.
+func (j *Job) Start() {
+	go func() {
+		for {
+			if _, _, err := j.RunOnce(context.Background()); err != nil {
+				log.Printf("[Reconciliation] %v", err)
+			}
+			time.Sleep(j.Interval)
+		}
+	}()
+}
+
+// RunOnce reconciles once and returns the discrepancies opened by this run
+// together with everything found, including those already open.
+func (j *Job) RunOnce(ctx context.Context) (opened, found []db.Discrepancy, err error) {
+	found, err = j.Store.FindDiscrepancies(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	opened, err = j.Store.RecordDiscrepancies(ctx, found, j.Freeze)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, d := range opened {
+		text := Describe(d)
+		if j.Freeze && d.UserID != nil {
+			text += " (wallet frozen)"
+		}
+		log.Printf("[Reconciliation] %s", text)
+		if j.Alert != nil {
+			j.Alert(text)
+		}
+	}
+	return opened, found, nil
+}
+
+// Describe returns a one-line summary of a discrepancy
+func Describe(d db.Discrepancy) string {
+	owner := "unknown"
+	switch {
+	case d.Kind == db.DiscrepancyWallet && d.UserID != nil:
+		owner = fmt.Sprintf("wallet of user %d", *d.UserID)
+	case d.Kind == db.DiscrepancyBonus && d.UserID != nil:
+		owner = fmt.Sprintf("bonus balance of user %d", *d.UserID)
+	case d.Kind == db.DiscrepancyRoom && d.RoomID != nil:
+		owner = fmt.Sprintf("escrow of room %d", *d.RoomID)
+	}
+	return fmt.Sprintf("Discrepancy %d: %s holds %s, expected %s (difference %s)",
+		d.ID, owner, d.Actual, d.Expected, d.Actual.Sub(d.Expected))
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"rockbingo/internal/db"
+	"time"
+)
+
+// DrawScheduler draws the numbers of every active session on the server, at
+// the draw interval of its room, and settles sessions whose claim window has
+// passed. The schedule lives in game_sessions.next_draw_at, so it survives
+// restarts, and each draw happens once however many instances run one.
+type DrawScheduler struct {
+	Store *db.SessionStore
+	Tick  time.Duration
+}
+
+// NewDrawScheduler returns a scheduler that checks for due draws every second.
+func NewDrawScheduler(store *db.SessionStore) *DrawScheduler {
+	return &DrawScheduler{
+		Store: store,
+		Tick:  time.Second,
+	}
+}
+
+// Start runs the scheduler in a background goroutine.
+func (d *DrawScheduler) Start() {
+	go func() {
+		for {
+			if _, err := d.RunOnce(context.Background()); err != nil {
+				log.Printf("[DrawScheduler] %v", err)
+			}
+			time.Sleep(d.Tick)
+		}
+	}()
+}
+
+// RunOnce makes every draw that is due and returns how many numbers it drew.
+func (d *DrawScheduler) RunOnce(ctx context.Context) (int, error) {
+	ids, err := d.Store.DueDraws(ctx)
+	if err != nil {
+		return 0, err
+	}
+	drawn := 0
+	for _, id := range ids {
+		number, err := d.Store.DrawScheduled(ctx, id)
+		switch {
+		case err == nil:
+			drawn++
+			log.Printf("[DrawScheduler] Session %d drew %d", id, number)
+		case errors.Is(err, db.ErrDrawNotDue), errors.Is(err, db.ErrClaimWindowOpen):
+			// Drawn by another instance, or bingo was called since
+		case errors.Is(err, db.ErrStageWon):
+			log.Printf("[DrawScheduler] Session %d moved to its next prize stage", id)
+		case errors.Is(err, db.ErrSessionEnded):
+			log.Printf("[DrawScheduler] Session %d ended", id)
+		default:
+			log.Printf("[DrawScheduler] Session %d: %v", id, err)
+		}
+	}
+	return drawn, nil
+}
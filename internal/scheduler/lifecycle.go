@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"rockbingo/internal/db"
+	"sync"
+	"time"
+)
+
+// Lifecycle moves rooms through their lifecycle: a room whose countdown has
+// ended gets a session, a room whose session has ended is completed, and a
+// completed room is recycled after RecycleAfter, replaced by a waiting room
+// for the same bet amount. Each step locks the rows it changes, so several
+// instances can run a worker side by side.
+type Lifecycle struct {
+	Rooms        *db.RoomStore
+	Sessions     *db.SessionStore
+	Tick         time.Duration
+	RecycleAfter time.Duration
+
+	mu      sync.Mutex
+	metrics LifecycleMetrics
+}
+
+// LifecycleMetrics counts what a worker has done since it started.
+type LifecycleMetrics struct {
+	Runs           int64      `json:"runs"`
+	RoomsStarted   int64      `json:"rooms_started"`
+	RoomsCompleted int64      `json:"rooms_completed"`
+	RoomsRecycled  int64      `json:"rooms_recycled"`
+	Errors         int64      `json:"errors"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastDuration   string     `json:"last_duration"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+}
+
+// LifecycleRun is what one pass of the worker did.
+type LifecycleRun struct {
+	Started   []int64  `json:"started"`
+	Completed []int64  `json:"completed"`
+	Recycled  []int64  `json:"recycled"`
+	Errors    []string `json:"errors"`
+}
+
+// NewLifecycle returns a worker that runs every 5 seconds and recycles rooms
+// 30 seconds after their game, leaving players time to see the result.
+func NewLifecycle(rooms *db.RoomStore, sessions *db.SessionStore) *Lifecycle {
+	return &Lifecycle{
+		Rooms:        rooms,
+		Sessions:     sessions,
+		Tick:         5 * time.Second,
+		RecycleAfter: 30 * time.Second,
+	}
+}
+
+// Start runs the worker in a background goroutine.
+func (l *Lifecycle) Start() {
+	go func() {
+		for {
+			l.RunOnce(context.Background())
+			time.Sleep(l.Tick)
+		}
+	}()
+}
+
+// Metrics returns a copy of the worker's metrics.
+func (l *Lifecycle) Metrics() LifecycleMetrics {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.metrics
+}
+
+// RunOnce moves every room that is due to its next stage. A failure with one
+// room is logged and recorded without stopping the others.
+func (l *Lifecycle) RunOnce(ctx context.Context) LifecycleRun {
+	begin := time.Now()
+	run := LifecycleRun{Started: []int64{}, Completed: []int64{}, Recycled: []int64{}, Errors: []string{}}
+	fail := func(err error) {
+		log.Printf("[Lifecycle] %v", err)
+		run.Errors = append(run.Errors, err.Error())
+	}
+
+	due, err := l.Rooms.DueRooms(ctx)
+	if err != nil {
+		fail(fmt.Errorf("listing rooms to start: %w", err))
+	}
+	for _, room := range due {
+		session, err := l.Sessions.StartSession(ctx, room.ID)
+		if err != nil {
+			fail(fmt.Errorf("starting room %d: %w", room.ID, err))
+			continue
+		}
+		// StartSession returns the session another instance started as is
+		if !session.SessionStartTime.Before(begin) {
+			log.Printf("[Lifecycle] Room %d started session %d", room.ID, session.ID)
+			run.Started = append(run.Started, room.ID)
+		}
+	}
+
+	completed, err := l.Rooms.CompleteRooms(ctx)
+	if err != nil {
+		fail(fmt.Errorf("completing rooms: %w", err))
+	}
+	for _, id := range completed {
+		log.Printf("[Lifecycle] Room %d completed", id)
+	}
+	run.Completed = append(run.Completed, completed...)
+
+	recyclable, err := l.Rooms.RecyclableRooms(ctx, l.RecycleAfter)
+	if err != nil {
+		fail(fmt.Errorf("listing rooms to recycle: %w", err))
+	}
+	for _, id := range recyclable {
+		next, err := l.Rooms.RecycleRoom(ctx, id, l.RecycleAfter)
+		if errors.Is(err, db.ErrRoomNotRecyclable) {
+			continue
+		}
+		if err != nil {
+			fail(fmt.Errorf("recycling room %d: %w", id, err))
+			continue
+		}
+		log.Printf("[Lifecycle] Room %d recycled; room %d is open for bet amount %s", id, next.ID, next.BetAmount)
+		run.Recycled = append(run.Recycled, id)
+	}
+
+	l.record(run, begin)
+	return run
+}
+
+func (l *Lifecycle) record(run LifecycleRun, begin time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := &l.metrics
+	m.Runs++
+	m.RoomsStarted += int64(len(run.Started))
+	m.RoomsCompleted += int64(len(run.Completed))
+	m.RoomsRecycled += int64(len(run.Recycled))
+	m.Errors += int64(len(run.Errors))
+	m.LastRunAt = &begin
+	m.LastDuration = time.Since(begin).String()
+	if len(run.Errors) > 0 {
+		m.LastError = run.Errors[len(run.Errors)-1]
+		m.LastErrorAt = &begin
+	}
+}
@@ -0,0 +1,95 @@
+package serviceauth
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("service-secret")
+	body := []byte(`{"amount":"10.00"}`)
+	now := time.Now()
+	ts := func(d time.Duration) string { return strconv.FormatInt(now.Add(d).Unix(), 10) }
+	sign := func(timestamp string) string {
+		return Sign(secret, "POST", "/api/withdraw", timestamp, "nonce-1", "42", body)
+	}
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		method    string
+		path      string
+		timestamp string
+		nonce     string
+		body      []byte
+		signature string
+		wantErr   bool
+	}{
+		{name: "valid", timestamp: ts(0), signature: sign(ts(0))},
+		{name: "within skew", timestamp: ts(-MaxSkew + time.Minute), signature: sign(ts(-MaxSkew + time.Minute))},
+		{name: "ahead within skew", timestamp: ts(MaxSkew - time.Minute), signature: sign(ts(MaxSkew - time.Minute))},
+		{name: "expired", timestamp: ts(-MaxSkew - time.Minute), signature: sign(ts(-MaxSkew - time.Minute)), wantErr: true},
+		{name: "from the future", timestamp: ts(MaxSkew + time.Minute), signature: sign(ts(MaxSkew + time.Minute)), wantErr: true},
+		{name: "bad timestamp", timestamp: "yesterday", signature: sign("yesterday"), wantErr: true},
+		{name: "tampered body", timestamp: ts(0), body: []byte(`{"amount":"99.00"}`), signature: sign(ts(0)), wantErr: true},
+		{name: "tampered path", timestamp: ts(0), path: "/api/deposit", signature: sign(ts(0)), wantErr: true},
+		{name: "tampered method", timestamp: ts(0), method: "PUT", signature: sign(ts(0)), wantErr: true},
+		{name: "other nonce", timestamp: ts(0), nonce: "nonce-2", signature: sign(ts(0)), wantErr: true},
+		{name: "missing nonce", timestamp: ts(0), nonce: "-", signature: sign(ts(0)), wantErr: true},
+		{name: "bad signature", timestamp: ts(0), signature: "00ff", wantErr: true},
+		{name: "other secret", secret: []byte("other-secret"), timestamp: ts(0), signature: sign(ts(0)), wantErr: true},
+		{name: "no secret", secret: []byte{}, timestamp: ts(0), signature: sign(ts(0)), wantErr: true},
+	}
+	for _, tt := range tests {
+		sec, method, path, nonce, b := secret, "POST", "/api/withdraw", "nonce-1", body
+		if tt.secret != nil {
+			sec = tt.secret
+		}
+		if tt.method != "" {
+			method = tt.method
+		}
+		if tt.path != "" {
+			path = tt.path
+		}
+		if tt.nonce == "-" {
+			nonce = ""
+		} else if tt.nonce != "" {
+			nonce = tt.nonce
+		}
+		if tt.body != nil {
+			b = tt.body
+		}
+		_, err := Verify(sec, method, path, tt.timestamp, nonce, "42", b, tt.signature)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Verify succeeded, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Verify error: %v", tt.name, err)
+		}
+	}
+}
+
+func TestVerifyNonceExpiry(t *testing.T) {
+	secret := []byte("service-secret")
+	issued := time.Now().Add(-time.Minute).Truncate(time.Second)
+	timestamp := strconv.FormatInt(issued.Unix(), 10)
+	sig := Sign(secret, "GET", "/api/wallet", timestamp, "n", "7", nil)
+	expiresAt, err := Verify(secret, "GET", "/api/wallet", timestamp, "n", "7", nil, sig)
+	if err != nil {
+		t.Fatalf("Verify error: %v", err)
+	}
+	if !expiresAt.Equal(issued.Add(MaxSkew)) {
+		t.Errorf("nonce expires at %v, want %v", expiresAt, issued.Add(MaxSkew))
+	}
+}
+
+func TestNewNonce(t *testing.T) {
+	a, b := NewNonce(), NewNonce()
+	if a == b || len(a) != 32 || len(a) > maxNonce {
+		t.Errorf("NewNonce() = %q, %q", a, b)
+	}
+}
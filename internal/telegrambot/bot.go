@@ -7,7 +7,10 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"rockbingo/internal/db"
+	"rockbingo/internal/serviceauth"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +29,12 @@ var (
 			tgbotapi.NewInlineKeyboardButtonData("Instructions", "instructions"),
 		},
 		{
+			tgbotapi.NewInlineKeyboardButtonData("My Withdrawals", "withdrawals"),
+			tgbotapi.NewInlineKeyboardButtonData("Payout Methods", "payout_methods"),
+		},
+		{
+			tgbotapi.NewInlineKeyboardButtonData("Redeem Promo", "promo"),
+			tgbotapi.NewInlineKeyboardButtonData("Statement", "statement"),
 			tgbotapi.NewInlineKeyboardButtonData("Invite", "invite"),
 		},
 	}
@@ -45,16 +54,41 @@ var (
 		sync.RWMutex
 		m map[int64]bool
 	}{m: make(map[int64]bool)}
+	// userWithdrawState holds the payout method a user is entering a withdrawal amount for
 	userWithdrawState = struct {
+		sync.RWMutex
+		m map[int64]int64
+	}{m: make(map[int64]int64)}
+	userPayoutMethodState = struct {
 		sync.RWMutex
 		m map[int64]bool
 	}{m: make(map[int64]bool)}
-	userIDMap = struct {
+	userPromoState = struct {
 		sync.RWMutex
-		m map[int64]int64
-	}{m: make(map[int64]int64)} // telegramID -> internal user ID
+		m map[int64]bool
+	}{m: make(map[int64]bool)}
 )
 
+// PayoutMethod is a saved withdrawal destination as returned by the API
+type PayoutMethod struct {
+	ID         int64   `json:"id"`
+	MethodType string  `json:"method_type"`
+	Account    string  `json:"account"`
+	BankCode   string  `json:"bank_code"`
+	Label      *string `json:"label"`
+	VerifiedAt *string `json:"verified_at"`
+}
+
+func (m PayoutMethod) name() string {
+	if m.Label != nil {
+		return fmt.Sprintf("%s (%s)", *m.Label, m.Account)
+	}
+	if m.BankCode != "" {
+		return fmt.Sprintf("%s %s", m.BankCode, m.Account)
+	}
+	return m.Account
+}
+
 type Room struct {
 	ID int64 `json:"id"`
 }
@@ -64,6 +98,9 @@ func StartBot(config *Config) {
 		log.Println("TELEGRAM_BOT_TOKEN not set, bot will not start.")
 		return
 	}
+	if config.ServiceSecret == "" {
+		log.Println("SERVICE_SECRET not set, the API will reject the bot's calls.")
+	}
 	bot, err := tgbotapi.NewBotAPI(config.BotToken)
 	if err != nil {
 		log.Println("Telegram bot error:", err)
@@ -82,18 +119,42 @@ func StartBot(config *Config) {
 				switch update.Message.Command() {
 				case "start":
 					registerOrSyncUser(config, update.Message.From)
+					if referrerID, ok := parseReferralPayload(update.Message.CommandArguments()); ok {
+						recordReferral(config, update.Message.From, referrerID)
+					}
 					sendWelcome(bot, update.Message)
+				case "referrals":
+					stats, err := getReferralStats(config, update.Message.From, bot.Self.UserName)
+					if err != nil {
+						stats = "Could not fetch your referrals."
+					}
+					bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, stats))
+				case "statement":
+					sendStatement(config, bot, update.Message.Chat.ID, update.Message.From)
+				case "promo":
+					if code := update.Message.CommandArguments(); code != "" {
+						bot.Send(handleRedeemPromo(config, update.Message, code))
+					} else {
+						setPromoState(update.Message.From.ID, true)
+						bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Enter your promo code:"))
+					}
 				}
 			} else if getDepositState(update.Message.From.ID) {
 				amount := update.Message.Text
 				msg := handleDeposit(config, bot, update.Message, amount)
 				bot.Send(msg)
 				setDepositState(update.Message.From.ID, false)
-			} else if getWithdrawState(update.Message.From.ID) {
-				amount := update.Message.Text
-				msg := handleWithdraw(config, bot, update.Message, amount)
+			} else if methodID := getWithdrawState(update.Message.From.ID); methodID != 0 {
+				msg := handleWithdraw(config, bot, update.Message, methodID, update.Message.Text)
+				bot.Send(msg)
+				setWithdrawState(update.Message.From.ID, 0)
+			} else if getPayoutMethodState(update.Message.From.ID) {
+				msg := handleAddPayoutMethod(config, update.Message, update.Message.Text)
 				bot.Send(msg)
-				setWithdrawState(update.Message.From.ID, false)
+				setPayoutMethodState(update.Message.From.ID, false)
+			} else if getPromoState(update.Message.From.ID) {
+				bot.Send(handleRedeemPromo(config, update.Message, update.Message.Text))
+				setPromoState(update.Message.From.ID, false)
 			}
 		}
 		if update.CallbackQuery != nil {
@@ -115,16 +176,40 @@ func setDepositState(userID int64, state bool) {
 	userDepositState.m[userID] = state
 }
 
-func getWithdrawState(userID int64) bool {
+func getWithdrawState(userID int64) int64 {
 	userWithdrawState.RLock()
 	defer userWithdrawState.RUnlock()
 	return userWithdrawState.m[userID]
 }
 
-func setWithdrawState(userID int64, state bool) {
+func setWithdrawState(userID int64, payoutMethodID int64) {
 	userWithdrawState.Lock()
 	defer userWithdrawState.Unlock()
-	userWithdrawState.m[userID] = state
+	userWithdrawState.m[userID] = payoutMethodID
+}
+
+func getPayoutMethodState(userID int64) bool {
+	userPayoutMethodState.RLock()
+	defer userPayoutMethodState.RUnlock()
+	return userPayoutMethodState.m[userID]
+}
+
+func setPayoutMethodState(userID int64, state bool) {
+	userPayoutMethodState.Lock()
+	defer userPayoutMethodState.Unlock()
+	userPayoutMethodState.m[userID] = state
+}
+
+func getPromoState(userID int64) bool {
+	userPromoState.RLock()
+	defer userPromoState.RUnlock()
+	return userPromoState.m[userID]
+}
+
+func setPromoState(userID int64, state bool) {
+	userPromoState.Lock()
+	defer userPromoState.Unlock()
+	userPromoState.m[userID] = state
 }
 
 func registerOrSyncUser(config *Config, user *tgbotapi.User) {
@@ -132,48 +217,139 @@ func registerOrSyncUser(config *Config, user *tgbotapi.User) {
 		return
 	}
 	body := map[string]interface{}{
-		"telegram_id": user.ID,
-		"username":    user.UserName,
-		"first_name":  user.FirstName,
-		"last_name":   user.LastName,
+		"username":   user.UserName,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
 	}
 	b, _ := json.Marshal(body)
-	resp, err := http.Post(fmt.Sprintf("%s/api/auth/telegram", config.APIBase), "application/json", bytes.NewBuffer(b))
-	if err != nil || resp.StatusCode != 200 {
+	req, err := newServiceRequest(config, "POST", "/api/service/users/sync", user.ID, b)
+	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-	var regResp struct {
-		ID int64 `json:"id"`
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to sync user %d: %v", user.ID, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// parseReferralPayload returns the Telegram ID of the referrer named by a
+// /start payload: ref_<id>, or room_<id> from links shared before referrals
+// were tracked
+func parseReferralPayload(payload string) (int64, bool) {
+	for _, prefix := range []string{"ref_", "room_"} {
+		if strings.HasPrefix(payload, prefix) {
+			id, err := strconv.ParseInt(strings.TrimPrefix(payload, prefix), 10, 64)
+			return id, err == nil && id > 0
+		}
+	}
+	return 0, false
+}
+
+// recordReferral records that the user opened the bot through referrerID's link
+func recordReferral(config *Config, user *tgbotapi.User, referrerID int64) {
+	if config.APIBase == "" || referrerID == user.ID {
+		return
+	}
+	b, _ := json.Marshal(map[string]int64{"referrer_telegram_id": referrerID})
+	req, err := newServiceRequest(config, "POST", "/api/referrals", user.ID, b)
+	if err != nil {
+		return
 	}
-	_ = json.NewDecoder(resp.Body).Decode(&regResp)
-	setInternalUserID(user.ID, regResp.ID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to record referral of %d by %d: %v", user.ID, referrerID, err)
+		return
+	}
+	resp.Body.Close()
 }
 
-func setInternalUserID(telegramID, internalID int64) {
-	userIDMap.Lock()
-	defer userIDMap.Unlock()
-	userIDMap.m[telegramID] = internalID
+// getReferralStats describes who the user invited and the rewards earned
+func getReferralStats(config *Config, user *tgbotapi.User, botUsername string) (string, error) {
+	req, err := newServiceRequest(config, "GET", "/api/referrals", user.ID, nil)
+	if err != nil {
+		return "Could not fetch your referrals.", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "Could not fetch your referrals.", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "Could not fetch your referrals.", nil
+	}
+	var stats struct {
+		Referrals      int      `json:"referrals"`
+		Qualified      int      `json:"qualified"`
+		Earned         db.Money `json:"earned"`
+		ReferrerReward db.Money `json:"referrer_reward"`
+		MinDeposit     db.Money `json:"min_deposit"`
+		MinWagered     db.Money `json:"min_wagered"`
+		Referees       []struct {
+			Username    string  `json:"username"`
+			FirstName   string  `json:"first_name"`
+			QualifiedAt *string `json:"qualified_at"`
+		} `json:"referees"`
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return "Could not fetch your referrals.", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Friends invited: %d\nQualified: %d\nEarned: %s", stats.Referrals, stats.Qualified, stats.Earned))
+	for i, r := range stats.Referees {
+		if i == 10 {
+			break
+		}
+		name := r.FirstName
+		if r.Username != "" {
+			name = "@" + r.Username
+		}
+		status := "waiting to qualify"
+		if r.QualifiedAt != nil {
+			status = "qualified"
+		}
+		sb.WriteString(fmt.Sprintf("\n- %s: %s", name, status))
+	}
+	if stats.ReferrerReward.IsPositive() {
+		sb.WriteString(fmt.Sprintf("\n\nYou earn %s for each friend who deposits %s and plays %s.",
+			stats.ReferrerReward, stats.MinDeposit, stats.MinWagered))
+	}
+	sb.WriteString("\nYour link: " + generateReferralLink(botUsername, user.ID))
+	return sb.String(), nil
 }
 
-func getInternalUserID(telegramID int64) int64 {
-	userIDMap.RLock()
-	defer userIDMap.RUnlock()
-	return userIDMap.m[telegramID]
+// newServiceRequest builds an API request acting for the given Telegram user,
+// signed with the service secret only the bot holds. The path is signed as
+// given, relative to APIBase, and each request gets a fresh nonce.
+func newServiceRequest(config *Config, method, path string, telegramID int64, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, config.APIBase+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := serviceauth.NewNonce()
+	telegramIDStr := strconv.FormatInt(telegramID, 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(serviceauth.HeaderTelegramID, telegramIDStr)
+	req.Header.Set(serviceauth.HeaderTimestamp, timestamp)
+	req.Header.Set(serviceauth.HeaderNonce, nonce)
+	req.Header.Set(serviceauth.HeaderSignature, serviceauth.Sign(
+		[]byte(config.ServiceSecret), method, path, timestamp, nonce, telegramIDStr, body))
+	return req, nil
 }
 
 func getUserBalance(config *Config, user *tgbotapi.User) (string, error) {
 	if config.APIBase == "" {
 		return "API not configured", nil
 	}
-	internalID := getInternalUserID(user.ID)
-	if internalID == 0 {
-		return "User not registered.", nil
+	req, err := newServiceRequest(config, "GET", "/api/wallet", user.ID, nil)
+	if err != nil {
+		return "API error", err
 	}
-	client := &http.Client{}
-	req, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/wallet", config.APIBase), nil)
-	req.Header.Set("X-User-ID", fmt.Sprintf("%d", internalID))
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "API error", err
 	}
@@ -182,11 +358,113 @@ func getUserBalance(config *Config, user *tgbotapi.User) (string, error) {
 		return "Could not fetch balance", nil
 	}
 	var wallet struct {
-		Balance float64 `json:"balance"`
+		Balance      db.Money   `json:"balance"`
+		BonusBalance db.Money   `json:"bonus_balance"`
+		FrozenAt     *time.Time `json:"frozen_at"`
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &wallet); err != nil {
+		return "Could not fetch balance", err
+	}
+	text := fmt.Sprintf("Your balance: %s", wallet.Balance)
+	if wallet.BonusBalance.IsPositive() {
+		text += fmt.Sprintf("\nBonus balance: %s", wallet.BonusBalance)
+	}
+	if wallet.FrozenAt != nil {
+		text += "\nYour wallet is frozen while we review it. Please contact support."
+	}
+	return text, nil
+}
+
+// getPayoutMethods fetches the user's saved payout methods
+func getPayoutMethods(config *Config, user *tgbotapi.User) ([]PayoutMethod, error) {
+	req, err := newServiceRequest(config, "GET", "/api/payout-methods", user.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("payout methods: status %d", resp.StatusCode)
+	}
+	var methods []PayoutMethod
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &methods); err != nil {
+		return nil, err
+	}
+	return methods, nil
+}
+
+// getUserWithdrawals lists the user's most recent withdrawal requests and their status
+func getUserWithdrawals(config *Config, user *tgbotapi.User) (string, error) {
+	req, err := newServiceRequest(config, "GET", "/api/withdrawals", user.ID, nil)
+	if err != nil {
+		return "Could not fetch withdrawals", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "Could not fetch withdrawals", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "Could not fetch withdrawals", nil
+	}
+	var withdrawals []struct {
+		ID     int64    `json:"id"`
+		Amount db.Money `json:"amount"`
+		Status string   `json:"status"`
+		Note   *string  `json:"note"`
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &withdrawals); err != nil {
+		return "Could not fetch withdrawals", err
+	}
+	if len(withdrawals) == 0 {
+		return "You have no withdrawal requests.", nil
+	}
+	var sb strings.Builder
+	sb.WriteString("Your withdrawals:")
+	for i, w := range withdrawals {
+		if i == 5 {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("\n#%d: %s - %s", w.ID, w.Amount, w.Status))
+		if w.Note != nil {
+			sb.WriteString(fmt.Sprintf(" (%s)", *w.Note))
+		}
+	}
+	return sb.String(), nil
+}
+
+// sendStatement sends the user's transaction history as a CSV document
+func sendStatement(config *Config, bot *tgbotapi.BotAPI, chatID int64, user *tgbotapi.User) {
+	req, err := newServiceRequest(config, "GET", "/api/transactions/export", user.ID, nil)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "Could not fetch statement."))
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "Could not fetch statement."))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Could not fetch statement."))
+		return
 	}
 	body, _ := ioutil.ReadAll(resp.Body)
-	_ = json.Unmarshal(body, &wallet)
-	return fmt.Sprintf("Your balance: %.2f ETB", wallet.Balance), nil
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("rockbingo-statement-%s.csv", time.Now().Format("2006-01-02")),
+		Bytes: body,
+	})
+	doc.Caption = "Your transaction statement"
+	if _, err := bot.Send(doc); err != nil {
+		log.Printf("[sendStatement] Send statement to %d: %v", chatID, err)
+	}
 }
 
 func sendWelcome(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
@@ -217,6 +495,14 @@ func handleCallback(config *Config, bot *tgbotapi.BotAPI, cb *tgbotapi.CallbackQ
 			balance = "Could not fetch balance."
 		}
 		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, balance))
+	case "statement":
+		sendStatement(config, bot, cb.Message.Chat.ID, cb.From)
+	case "withdrawals":
+		withdrawals, err := getUserWithdrawals(config, cb.From)
+		if err != nil {
+			withdrawals = "Could not fetch withdrawals."
+		}
+		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, withdrawals))
 	case "instructions":
 		instructions := `📋 *How to Play Rock Bingo* 📋\n\n
 1️⃣ *Start*: Tap /start to register and see the main menu.\n
@@ -231,12 +517,59 @@ func handleCallback(config *Config, bot *tgbotapi.BotAPI, cb *tgbotapi.CallbackQ
 		msg.ParseMode = "Markdown"
 		bot.Send(msg)
 	case "invite":
-		inviteLink := generateInviteLink(bot.Self.UserName, cb.From.ID)
+		inviteLink := generateReferralLink(bot.Self.UserName, cb.From.ID)
 
-		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Invite your friends with this link: "+inviteLink))
+		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Invite your friends with this link: "+inviteLink+"\nUse /referrals to see who joined and what you earned."))
 	case "withdraw":
-		setWithdrawState(cb.From.ID, true)
-		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Enter the amount you want to withdraw (ETB):"))
+		methods, err := getPayoutMethods(config, cb.From)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Could not fetch your payout methods."))
+			break
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, m := range methods {
+			if m.VerifiedAt != nil {
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData(m.name(), fmt.Sprintf("withdraw_to_%d", m.ID))))
+			}
+		}
+		if len(rows) == 0 {
+			bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "You have no verified payout method yet. Add one under Payout Methods; you can withdraw to it once it is verified."))
+			break
+		}
+		msg := tgbotapi.NewMessage(cb.Message.Chat.ID, "Where should we send the money?")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		bot.Send(msg)
+	case "payout_methods":
+		methods, err := getPayoutMethods(config, cb.From)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Could not fetch your payout methods."))
+			break
+		}
+		text := "You have no payout methods."
+		if len(methods) > 0 {
+			var sb strings.Builder
+			sb.WriteString("Your payout methods:")
+			for _, m := range methods {
+				status := "awaiting verification"
+				if m.VerifiedAt != nil {
+					status = "verified"
+				}
+				sb.WriteString(fmt.Sprintf("\n%s - %s", m.name(), status))
+			}
+			text = sb.String()
+		}
+		msg := tgbotapi.NewMessage(cb.Message.Chat.ID, text)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Add mobile money number", "add_payout_method"),
+		))
+		bot.Send(msg)
+	case "promo":
+		setPromoState(cb.From.ID, true)
+		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Enter your promo code:"))
+	case "add_payout_method":
+		setPayoutMethodState(cb.From.ID, true)
+		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Send your mobile money number, e.g. 0911223344:"))
 	case "play_10", "play_25", "play_50", "play_100":
 		amount := cb.Data[5:]
 		if config.MiniAppURL == "" {
@@ -257,71 +590,163 @@ func handleCallback(config *Config, bot *tgbotapi.BotAPI, cb *tgbotapi.CallbackQ
 		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
 		bot.Send(msg)
 	default:
+		if strings.HasPrefix(cb.Data, "withdraw_to_") {
+			methodID, err := strconv.ParseInt(strings.TrimPrefix(cb.Data, "withdraw_to_"), 10, 64)
+			if err == nil {
+				setWithdrawState(cb.From.ID, methodID)
+				bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Enter the amount you want to withdraw (ETB):"))
+				break
+			}
+		}
 		bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, "Unknown action."))
 	}
 	bot.Request(tgbotapi.NewCallback(cb.ID, ""))
 }
 
 func handleDeposit(config *Config, bot *tgbotapi.BotAPI, msg *tgbotapi.Message, amountStr string) tgbotapi.MessageConfig {
-	amount, err := strconv.ParseFloat(amountStr, 64)
-	if err != nil || amount <= 0 {
-		return tgbotapi.NewMessage(msg.Chat.ID, "Invalid amount. Please enter a positive number.")
-	}
-	internalID := getInternalUserID(msg.From.ID)
-	if internalID == 0 {
-		return tgbotapi.NewMessage(msg.Chat.ID, "User not registered.")
+	amount, err := db.ParseMoney(amountStr, db.DefaultCurrency)
+	if err != nil || !amount.IsPositive() {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Invalid amount. Please enter a positive number with at most 2 decimals.")
 	}
-	// Generate a unique transaction_ref using user ID and current timestamp
-	transactionRef := fmt.Sprintf("telegram-%d-%d", msg.From.ID, time.Now().UnixNano())
+	// Derive the transaction_ref from the chat message so a redelivered update reuses it
+	transactionRef := fmt.Sprintf("telegram-%d-%d", msg.Chat.ID, msg.MessageID)
 	depReq := map[string]interface{}{
 		"amount":          amount,
-		"currency":        "ETB",
+		"currency":        amount.Cur(),
 		"transaction_ref": transactionRef,
-		"status":          "completed", // <-- set to completed for auto-credit
 	}
 	b, _ := json.Marshal(depReq)
-	client := &http.Client{}
-	req, _ := http.NewRequest("POST", config.APIBase+"/api/deposit", bytes.NewBuffer(b))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-User-ID", fmt.Sprintf("%d", internalID))
-	resp, err := client.Do(req)
-	if err != nil || resp.StatusCode != 200 {
+	req, err := newServiceRequest(config, "POST", "/api/deposit", msg.From.ID, b)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Deposit failed. Please try again later.")
+	}
+	// One key per chat message, so a retried delivery cannot open a second deposit
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("deposit-%d-%d", msg.Chat.ID, msg.MessageID))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Deposit failed. Please try again later.")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == 403 {
+		// Refused by the user's own deposit limits or self-exclusion
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		json.Unmarshal(body, &apiErr)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Deposit refused: %s", apiErr.Message))
+	}
+	if resp.StatusCode != 201 {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Deposit failed. Please try again later.")
+	}
+	var deposit struct {
+		CheckoutURL *string `json:"checkout_url"`
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &deposit); err != nil || deposit.CheckoutURL == nil {
 		return tgbotapi.NewMessage(msg.Chat.ID, "Deposit failed. Please try again later.")
 	}
-	return tgbotapi.NewMessage(msg.Chat.ID, "Deposit request received! Please complete your payment as instructed.")
+	// The wallet is credited once the payment provider confirms the payment
+	reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Deposit of %s created. Complete your payment and your balance will be updated once it is confirmed.", amount))
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonURL("Pay now", *deposit.CheckoutURL),
+	))
+	return reply
 }
 
-func handleWithdraw(config *Config, bot *tgbotapi.BotAPI, msg *tgbotapi.Message, amountStr string) tgbotapi.MessageConfig {
-	amount, err := strconv.ParseFloat(amountStr, 64)
-	if err != nil || amount <= 0 {
-		return tgbotapi.NewMessage(msg.Chat.ID, "Invalid amount. Please enter a positive number.")
-	}
-	internalID := getInternalUserID(msg.From.ID)
-	if internalID == 0 {
-		return tgbotapi.NewMessage(msg.Chat.ID, "User not registered.")
+// handleWithdraw requests a withdrawal of amountStr to a saved payout method
+func handleWithdraw(config *Config, bot *tgbotapi.BotAPI, msg *tgbotapi.Message, payoutMethodID int64, amountStr string) tgbotapi.MessageConfig {
+	amount, err := db.ParseMoney(strings.TrimSpace(amountStr), db.DefaultCurrency)
+	if err != nil || !amount.IsPositive() {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Invalid amount. Please enter a positive number with at most 2 decimals.")
 	}
 	withdrawReq := map[string]interface{}{
-		"amount":      amount,
-		"currency":    "ETB",
-		"destination": "telegram-withdrawal",
+		"amount":           amount,
+		"currency":         amount.Cur(),
+		"payout_method_id": payoutMethodID,
 	}
 	b, _ := json.Marshal(withdrawReq)
-	client := &http.Client{}
-	req, _ := http.NewRequest("POST", config.APIBase+"/api/withdraw", bytes.NewBuffer(b))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-User-ID", fmt.Sprintf("%d", internalID))
-	resp, err := client.Do(req)
+	req, err := newServiceRequest(config, "POST", "/api/withdraw", msg.From.ID, b)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Withdraw failed. Please try again later.")
+	}
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("withdraw-%d-%d", msg.Chat.ID, msg.MessageID))
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return tgbotapi.NewMessage(msg.Chat.ID, "Withdraw failed. Please try again later.")
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		body, _ := ioutil.ReadAll(resp.Body)
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
 		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Withdraw failed: %s", string(body)))
 	}
-	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Withdraw request for %.2f ETB received!", amount))
+	var withdrawal struct {
+		ID          int64  `json:"id"`
+		Destination string `json:"destination"`
+	}
+	json.Unmarshal(body, &withdrawal)
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Withdrawal request #%d for %s to %s is pending review. The amount is held from your balance until it is paid out.", withdrawal.ID, amount, withdrawal.Destination))
+}
+
+// handleAddPayoutMethod saves a mobile money number as a payout method
+func handleAddPayoutMethod(config *Config, msg *tgbotapi.Message, number string) tgbotapi.MessageConfig {
+	destination, err := db.PayoutDestination{MethodType: db.PayoutMobileMoney, Account: strings.TrimSpace(number)}.Normalize()
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Invalid mobile money number. Please use a number like 0911223344.")
+	}
+	b, _ := json.Marshal(destination)
+	req, err := newServiceRequest(config, "POST", "/api/payout-methods", msg.From.ID, b)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Could not save the payout method. Please try again later.")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Could not save the payout method. Please try again later.")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 201 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Could not save the payout method: %s", string(body)))
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("%s saved. You can withdraw to it once it has been verified.", destination.Account))
+}
+
+// handleRedeemPromo redeems a promo code for the user
+func handleRedeemPromo(config *Config, msg *tgbotapi.Message, code string) tgbotapi.MessageConfig {
+	b, _ := json.Marshal(map[string]string{"code": strings.TrimSpace(code)})
+	req, err := newServiceRequest(config, "POST", "/api/promo/redeem", msg.From.ID, b)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Could not redeem the promo code. Please try again later.")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return tgbotapi.NewMessage(msg.Chat.ID, "Could not redeem the promo code. Please try again later.")
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != 201 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &apiErr)
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Could not redeem the promo code: %s", apiErr.Message))
+	}
+	var redemption struct {
+		Status           string   `json:"status"`
+		BonusAmount      db.Money `json:"bonus_amount"`
+		WageringRequired db.Money `json:"wagering_required"`
+	}
+	json.Unmarshal(body, &redemption)
+	switch redemption.Status {
+	case db.RedemptionPendingDeposit:
+		return tgbotapi.NewMessage(msg.Chat.ID, "Promo code applied! Your bonus is credited with your next deposit.")
+	case db.RedemptionCompleted:
+		return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Promo code applied! %s has been added to your balance.", redemption.BonusAmount))
+	}
+	return tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Promo code applied! %s bonus credited. Bet %s to make it withdrawable.", redemption.BonusAmount, redemption.WageringRequired))
 }
 
-func generateInviteLink(botUsername string, roomID int64) string {
-	return fmt.Sprintf("https://t.me/%s?start=room_%d", botUsername, roomID)
+// generateReferralLink returns the link that opens the bot with the user as referrer
+func generateReferralLink(botUsername string, telegramID int64) string {
+	return fmt.Sprintf("https://t.me/%s?start=ref_%d", botUsername, telegramID)
 }
@@ -8,12 +8,15 @@ type Config struct {
 	BotToken   string
 	APIBase    string
 	MiniAppURL string
+	// ServiceSecret signs the bot's API calls on behalf of Telegram users.
+	ServiceSecret string
 }
 
 func LoadConfig() (*Config, error) {
 	return &Config{
-		BotToken:   os.Getenv("TELEGRAM_BOT_TOKEN"),
-		APIBase:    os.Getenv("API_BASE"),
-		MiniAppURL: os.Getenv("MINIAPP_URL"),
+		BotToken:      os.Getenv("TELEGRAM_BOT_TOKEN"),
+		APIBase:       os.Getenv("API_BASE"),
+		MiniAppURL:    os.Getenv("MINIAPP_URL"),
+		ServiceSecret: os.Getenv("SERVICE_SECRET"),
 	}, nil
 }
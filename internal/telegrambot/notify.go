@@ -0,0 +1,45 @@
+package telegrambot
+
+import (
+	"log"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// AdminNotifier sends operational alerts to admins over Telegram. It does
+// nothing without a bot token or admin chats.
+type AdminNotifier struct {
+	BotToken string
+	ChatIDs  []int64
+
+	once sync.Once
+	bot  *tgbotapi.BotAPI
+}
+
+func NewAdminNotifier(botToken string, chatIDs []int64) *AdminNotifier {
+	return &AdminNotifier{BotToken: botToken, ChatIDs: chatIDs}
+}
+
+// Notify sends text to every admin chat
+func (n *AdminNotifier) Notify(text string) {
+	if n.BotToken == "" || len(n.ChatIDs) == 0 {
+		return
+	}
+	n.once.Do(func() {
+		bot, err := tgbotapi.NewBotAPI(n.BotToken)
+		if err != nil {
+			log.Printf("[AdminNotifier] Telegram bot error: %v", err)
+			return
+		}
+		n.bot = bot
+	})
+	if n.bot == nil {
+		return
+	}
+	for _, chatID := range n.ChatIDs {
+		if _, err := n.bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+			log.Printf("[AdminNotifier] Notify chat %d: %v", chatID, err)
+		}
+	}
+}
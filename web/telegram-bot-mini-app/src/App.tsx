@@ -24,7 +24,7 @@ function App() {
   const [roomError, setRoomError] = useState<string | null>(null);
   const [rooms, setRooms] = useState<Room[]>([]);
   const [selectedCard, setSelectedCard] = useState<any>(null);
-  const { user: telegramUser } = useTelegram();
+  const { user: telegramUser, webApp } = useTelegram();
   
   // Get bet amount from URL parameters
   const betAmount = getBetAmount();
@@ -35,14 +35,9 @@ function App() {
   // Authenticate and set user
   useEffect(() => {
     if (telegramUser) {
-      apiService.authenticateTelegram({
-        telegram_id: telegramUser.id,
-        username: telegramUser.username,
-        first_name: telegramUser.first_name,
-        last_name: telegramUser.last_name,
-      }).then(setUser);
+      apiService.authenticateTelegram(webApp?.initData ?? '').then(setUser);
     }
-  }, [telegramUser]);
+  }, [telegramUser, webApp]);
 
   // Reset join attempt when user changes
   useEffect(() => {
@@ -1,10 +1,8 @@
 import React from 'react';
+import { CardData } from '../types';
 
 interface BingoCardProps {
-  cardData: {
-    grid: number[][];
-    marks: boolean[][];
-  };
+  cardData: CardData;
   cardNumber: number;
   onNumberClick?: (row: number, col: number, number: number) => void;
   disabled?: boolean;
@@ -24,9 +22,20 @@ const isValidNumberForColumn = (col: number, num: number) => {
   }
 };
 
+// 90-ball columns hold 1-9, 10-19 ... 70-79 and 80-90
+const isValidNumberForTicketColumn = (col: number, num: number) => {
+  const low = col === 0 ? 1 : col * 10;
+  const high = col === 8 ? 90 : col * 10 + 9;
+  return num >= low && num <= high;
+};
+
 const isCenter = (row: number, col: number) => row === 2 && col === 2;
 
 function BingoCardComponent({ cardData, cardNumber, onNumberClick, disabled = false }: BingoCardProps) {
+  if (cardData.variant === '90_ball') {
+    return <BingoTicket cardData={cardData} cardNumber={cardNumber} onNumberClick={onNumberClick} disabled={disabled} />;
+  }
+
   const handleNumberClick = (row: number, col: number, number: number) => {
     if (!disabled && onNumberClick && !isCenter(row, col)) {
       onNumberClick(row, col, number);
@@ -113,4 +122,60 @@ function BingoCardComponent({ cardData, cardNumber, onNumberClick, disabled = fa
   );
 }
 
+// A 90-ball ticket: 3 rows of 9 columns, five numbers to a row and the
+// other cells left blank
+function BingoTicket({ cardData, cardNumber, onNumberClick, disabled = false }: BingoCardProps) {
+  return (
+    <div className="bg-white rounded-lg shadow-lg p-4 max-w-md mx-auto select-none">
+      <div className="text-center mb-4">
+        <h3 className="text-lg font-bold text-gray-900">90 BALL</h3>
+        <p className="text-sm text-gray-600">
+          Ticket #{cardNumber}{cardData.strip ? ` · Strip ${cardData.strip}` : ''}
+        </p>
+      </div>
+
+      <div className="grid grid-cols-9 gap-1">
+        {cardData.grid.map((row, rowIndex) =>
+          row.map((number, colIndex) => {
+            if (number === 0) {
+              return <div key={`${rowIndex}-${colIndex}`} className="aspect-square rounded bg-purple-100" aria-hidden="true" />;
+            }
+            const validNumber = isValidNumberForTicketColumn(colIndex, number);
+            const marked = cardData.marks[rowIndex][colIndex];
+
+            return (
+              <button
+                key={`${rowIndex}-${colIndex}`}
+                type="button"
+                onClick={() => !disabled && onNumberClick?.(rowIndex, colIndex, number)}
+                disabled={disabled}
+                aria-pressed={marked}
+                aria-label={marked ? `Marked number ${number}` : `Number ${number}`}
+                className={`
+                  aspect-square rounded border-2 font-bold text-xs transition-all duration-300
+                  flex items-center justify-center
+                  focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-purple-500
+                  ${marked
+                    ? 'bg-green-500 border-green-600 text-white shadow-lg'
+                    : 'bg-gray-50 border-gray-300 text-gray-700 hover:bg-gray-100 cursor-pointer'
+                  }
+                  ${disabled ? 'opacity-70 cursor-not-allowed' : ''}
+                `}
+              >
+                {validNumber ? number : <span className="text-red-500 text-xs">ERR</span>}
+              </button>
+            );
+          })
+        )}
+      </div>
+
+      <div className="text-center mt-4">
+        <p className="text-xs text-gray-500 select-none">
+          Tap numbers as they’re called to mark them.
+        </p>
+      </div>
+    </div>
+  );
+}
+
 export const BingoCard = React.memo(BingoCardComponent);
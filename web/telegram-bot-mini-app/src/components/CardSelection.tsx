@@ -171,7 +171,9 @@ export function CardSelection({
       <div className="max-w-7xl mx-auto p-4">
         <div className="mb-6">
           <p className="text-gray-600 text-center mb-4">
-            Choose one of the 100 available bingo cards. Each card has 25 unique numbers from 1-100.
+            {availableCards?.[0]?.card_data?.variant === '90_ball'
+              ? `Choose one of the ${availableCards.length} available tickets. Each has 15 numbers from 1-90, and every strip of six holds each number once.`
+              : 'Choose one of the 100 available bingo cards. Each card has 24 numbers from 1-75 and a free space.'}
           </p>
           <div className="text-center">
             <span className="text-sm text-gray-500">
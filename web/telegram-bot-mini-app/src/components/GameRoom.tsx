@@ -12,6 +12,7 @@ import { GameRoomModals } from './GameRoom/GameRoomModals';
 import { GameRoomDrawnNumbers } from './GameRoom/GameRoomDrawnNumbers';
 import { GameRoomPlayerList } from './GameRoom/GameRoomPlayerList';
 import { GameRoomPrizePool } from './GameRoom/GameRoomPrizePool';
+import { GameRoomWinPattern } from './GameRoom/GameRoomWinPattern';
 import { GameRoomControls } from './GameRoom/GameRoomControls';
 import { GameRoomCardSection } from './GameRoom/GameRoomCardSection';
 import { GameRoomCardSelectionWrapper } from './GameRoom/GameRoomCardSelectionWrapper';
@@ -131,8 +132,23 @@ interface GameRoomProps {
   onBack: () => void;
 }
 
+// Explains what happened to the pot of a session that ended without a winner
+function noWinnerMessage(session: GameSession | null): string | undefined {
+  const amount = session?.outcome_amount != null ? `${Number(session.outcome_amount).toFixed(2)} ETB` : 'The pot';
+  switch (session?.outcome) {
+    case 'refunded':
+      return 'No one won this round. Your bet has been refunded to your wallet.';
+    case 'rolled_over':
+      return `No one won this round. ${amount} rolls over to the next game at this bet.`;
+    case 'jackpot':
+      return `No one won this round. ${amount} has been added to the jackpot.`;
+    default:
+      return undefined;
+  }
+}
+
 export function GameRoom({ room, onBack }: GameRoomProps) {
-  const { user: telegramUser } = useTelegram();
+  const { user: telegramUser, webApp } = useTelegram();
   const [state, dispatch] = useReducer(gameRoomReducer, initialState);
 
   const {
@@ -160,16 +176,11 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
   // Authenticate user on telegramUser change
   useEffect(() => {
     if (!telegramUser) return;
-    apiService.authenticateTelegram({
-      telegram_id: telegramUser.id,
-      username: telegramUser.username,
-      first_name: telegramUser.first_name,
-      last_name: telegramUser.last_name,
-    }).then((user: User) => {
+    apiService.authenticateTelegram(webApp?.initData ?? '').then((user: User) => {
       dispatch({ type: 'SET_USER', payload: user });
       apiService.setAuthUser(user);
     }).catch(() => dispatch({ type: 'SET_USER', payload: null }));
-  }, [telegramUser]);
+  }, [telegramUser, webApp]);
 
   // Load game data
   const loadGameData = useCallback(async () => {
@@ -244,18 +255,6 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
         const playersData = await apiService.getRoomPlayers(room.id);
         dispatch({ type: 'SET_GAME_DATA', payload: { players: playersData } });
 
-        if (countdownData?.time_left <= 0 && (!gameSession || gameSession.status !== 'active')) {
-          try {
-            await apiService.createSession(room.id);
-            const newSession = await apiService.getRoomSession(room.id);
-            dispatch({ type: 'SET_SESSION', payload: newSession });
-            if (newSession?.drawn_numbers) {
-              dispatch({ type: 'SET_DRAWN_NUMBERS', payload: newSession.drawn_numbers });
-            }
-          } catch {
-            // ignore if session exists
-          }
-        }
       } catch {
         // optionally handle poll errors
       }
@@ -267,7 +266,7 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
     return () => { polling = false; };
   }, [room.id, user]);
 
-  // Handlers: mark number, draw number, claim bingo
+  // Handlers: mark number, claim bingo. Numbers are drawn by the server and picked up by polling
   const handleMarkNumber = (number: number) => {
     if (!session || !selectedCard || !user) return;
     apiService.markNumber(session.id, Number(selectedCard.id), number)
@@ -285,24 +284,11 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
       });
   };
 
-  const handleDrawNumber = async () => {
-    if (!session || !user) return;
-    try {
-      const result = await apiService.drawNumber(session.id);
-      dispatch({ type: 'SET_DRAWN_NUMBERS', payload: [...drawnNumbers, result.number] });
-      dispatch({ type: 'SET_ACTION_MESSAGE', payload: `Number ${result.number} drawn!` });
-      setTimeout(() => dispatch({ type: 'SET_ACTION_MESSAGE', payload: null }), 1500);
-      loadGameData();
-    } catch {
-      dispatch({ type: 'SET_ERROR', payload: 'Failed to draw number.' });
-    }
-  };
-
   const handleClaimBingo = async () => {
     if (!session || !selectedCard || !user) return;
     try {
       await apiService.claimBingo(session.id, Number(selectedCard.id));
-      dispatch({ type: 'SET_ACTION_MESSAGE', payload: 'Bingo claimed! Waiting for validation...' });
+      dispatch({ type: 'SET_ACTION_MESSAGE', payload: 'Bingo claimed! Waiting for other claims before the pot is split...' });
       setTimeout(() => dispatch({ type: 'SET_ACTION_MESSAGE', payload: null }), 2000);
       loadGameData();
     } catch (error) {
@@ -319,7 +305,8 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
   const handleStartGame = async () => {
     if (!room || !user) return;
     try {
-      const newSession = await apiService.createSession(room.id);
+      // The server starts the game when the countdown ends; pick up its session
+      const newSession = await apiService.getRoomSession(room.id);
       dispatch({ type: 'SET_SESSION', payload: newSession });
       dispatch({ type: 'SET_ACTION_MESSAGE', payload: 'Game started!' });
       setTimeout(() => dispatch({ type: 'SET_ACTION_MESSAGE', payload: null }), 1500);
@@ -365,24 +352,6 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
     }
   }, [session, showCardSelection, forceCardSelection]);
 
-  // Auto-draw numbers every 5 seconds during active game
-  useEffect(() => {
-    if (!session || session.status !== 'active') return;
-
-    const interval = setInterval(async () => {
-      try {
-        const result = await apiService.autoDrawNumber(session.id);
-        dispatch({ type: 'SET_DRAWN_NUMBERS', payload: [...drawnNumbers, result.number] });
-        dispatch({ type: 'SET_ACTION_MESSAGE', payload: `Number ${result.number} called automatically!` });
-        setTimeout(() => dispatch({ type: 'SET_ACTION_MESSAGE', payload: null }), 2000);
-      } catch {
-        // ignore errors
-      }
-    }, 5000);
-
-    return () => clearInterval(interval);
-  }, [session?.id, session?.status, drawnNumbers]);
-
   // Determine game phase
   type GamePhase = 'waiting' | 'finished' | 'active' | 'ready' | 'countdown';
   let gamePhase: GamePhase = 'waiting';
@@ -558,6 +527,7 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
         handleSelectAnotherCard={handleSelectAnotherCard}
         handleLeaveRoom={handleLeaveRoom}
         showGameOverModal={showGameOverModal}
+        gameOverMessage={noWinnerMessage(session)}
         setShowGameOverModal={(val: boolean) => dispatch({ type: val ? 'SHOW_GAME_OVER_MODAL' : 'HIDE_GAME_OVER_MODAL' })}
         onBack={onBack}
       />
@@ -573,6 +543,7 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
         <div className="flex items-center gap-2">
           <GameRoomPrizePool players={players} room={room} />
         </div>
+        <GameRoomWinPattern roomId={room.id} sessionId={session?.id} stage={session?.current_stage} />
       </div>
       <main className="max-w-4xl mx-auto p-4">
         <div style={{ minHeight: 32 }}>
@@ -584,6 +555,7 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
           user={user}
           players={players}
           countdown={countdown}
+          claimWindowOpen={session?.status === 'active' && !!session?.claim_deadline}
           handleStartGame={handleStartGame}
         />
         <div className="grid grid-cols-1 md:grid-cols-3 gap-6">
@@ -592,7 +564,7 @@ export function GameRoom({ room, onBack }: GameRoomProps) {
           </div>
           <div className="space-y-4">
             <GameRoomDrawnNumbers drawnNumbers={safeDrawnNumbers} latestNumber={latestNumber} />
-            <GameRoomControls session={session} handleDrawNumber={handleDrawNumber} handleClaimBingo={handleClaimBingo} />
+            <GameRoomControls session={session} handleClaimBingo={handleClaimBingo} />
             <GameRoomPlayerList players={safePlayers} user={user} />
           </div>
         </div>
@@ -9,6 +9,7 @@ interface GameRoomModalsProps {
   handleSelectAnotherCard: () => void;
   handleLeaveRoom: () => void;
   showGameOverModal: boolean;
+  gameOverMessage?: string;
   setShowGameOverModal: (value: boolean) => void;
   onBack: () => void;
 }
@@ -21,6 +22,7 @@ export function GameRoomModals({
   handleSelectAnotherCard,
   handleLeaveRoom,
   showGameOverModal,
+  gameOverMessage = 'Better luck next time!',
   setShowGameOverModal,
   onBack,
 }: GameRoomModalsProps) {
@@ -72,7 +74,7 @@ export function GameRoomModals({
           <div className="bg-white rounded-lg shadow-xl p-8 max-w-sm w-full text-center animate-fade-in">
             <X className="h-12 w-12 text-gray-400 mx-auto mb-4 animate-bounce" />
             <h2 className="text-2xl font-bold text-gray-700 mb-2">Game Over</h2>
-            <p className="text-lg text-gray-800 mb-4">Better luck next time!</p>
+            <p className="text-lg text-gray-800 mb-4">{gameOverMessage}</p>
             <button
               onClick={() => {
                 setShowGameOverModal(false);
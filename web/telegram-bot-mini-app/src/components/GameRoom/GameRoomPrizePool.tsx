@@ -1,8 +1,12 @@
 
 import { Trophy } from 'lucide-react';
+import { formatRake, rakeFor } from '../../utils/rake';
 
 interface Room {
   bet_amount?: number;
+  rake_bps?: number;
+  rake_min?: number;
+  rake_cap?: number | null;
 }
 
 interface GameRoomPrizePoolProps {
@@ -11,13 +15,16 @@ interface GameRoomPrizePoolProps {
 }
 
 export function GameRoomPrizePool({ players, room }: GameRoomPrizePoolProps) {
-  const prizePool = (players.length * (room.bet_amount ?? 0)).toFixed(2);
+  const pot = players.length * (room.bet_amount ?? 0);
+  const prizePool = (pot - rakeFor(room, pot)).toFixed(2);
+  const houseFee = formatRake(room);
   
   return (
     <div className="flex items-center gap-2">
       <Trophy className="h-5 w-5 text-yellow-500" />
       <span className="font-semibold text-gray-700">Prize Pool:</span>
       <span className="text-lg font-bold text-purple-700">{prizePool} ETB</span>
+      {houseFee && <span className="text-xs text-gray-500">after {houseFee} house fee</span>}
     </div>
   );
 }
@@ -19,6 +19,7 @@ interface GameRoomStatusBannerProps {
   user?: User | null;
   players: Player[];
   countdown?: Countdown | null;
+  claimWindowOpen?: boolean;
   handleStartGame: () => void;
 }
 
@@ -28,6 +29,7 @@ export function GameRoomStatusBanner({
   user,
   players,
   countdown,
+  claimWindowOpen,
   handleStartGame,
 }: GameRoomStatusBannerProps) {
   return (
@@ -65,11 +67,17 @@ export function GameRoomStatusBanner({
           )}
         </div>
       )}
-      {gamePhase === 'active' && (
+      {gamePhase === 'active' && !claimWindowOpen && (
         <div className="mb-4 text-center text-green-700 font-semibold">
           Game in progress!
         </div>
       )}
+      {gamePhase === 'active' && claimWindowOpen && (
+        <div className="mb-4 text-center text-orange-600 font-semibold animate-pulse">
+          Bingo has been called!<br />
+          Claim now if you have bingo too — the pot is split among everyone who claims in time.
+        </div>
+      )}
       {gamePhase === 'finished' && (
         <div className="mb-4 text-center text-gray-700 font-semibold">
           Game finished. {winner ? 'You won!' : 'Better luck next time!'}
@@ -0,0 +1,54 @@
+import { useEffect, useState } from 'react';
+import { apiService } from '../../services/api';
+import { RoomRules } from '../../types';
+
+interface GameRoomWinPatternProps {
+  roomId: string;
+  sessionId?: string | null;
+  stage?: number;
+}
+
+function patternLabel(name: string) {
+  return name
+    .split('_')
+    .map((word) => word.charAt(0).toUpperCase() + word.slice(1))
+    .join(' ');
+}
+
+export function GameRoomWinPattern({ roomId, sessionId, stage }: GameRoomWinPatternProps) {
+  const [rules, setRules] = useState<RoomRules | null>(null);
+
+  // The pattern can change until the game starts and with each prize stage,
+  // so reload it with the session and its stage
+  useEffect(() => {
+    apiService.getRoomRules(roomId)
+      .then(setRules)
+      .catch(() => setRules(null));
+  }, [roomId, sessionId, stage]);
+
+  const pattern = rules?.win_pattern;
+  if (!rules || !pattern || pattern.masks.length === 0) return null;
+  const stages = rules.prize_stages ?? [];
+  const mask = pattern.masks[0];
+  const others = pattern.masks.length - 1;
+
+  return (
+    <div className="flex items-center gap-2">
+      <div className="grid gap-px" style={{ gridTemplateColumns: `repeat(${mask[0]?.length ?? 5}, minmax(0, 1fr))` }}>
+        {mask.flatMap((row, i) =>
+          row.map((cell, j) => (
+            <div key={`${i}-${j}`} className={`h-2 w-2 rounded-sm ${cell ? 'bg-purple-600' : 'bg-gray-200'}`} />
+          ))
+        )}
+      </div>
+      <span className="font-semibold text-gray-700">Win:</span>
+      <span className="text-sm font-bold text-purple-700">{patternLabel(pattern.name)}</span>
+      {others > 0 && <span className="text-xs text-gray-500">or any of {others} more</span>}
+      {stages.length > 1 && (
+        <span className="text-xs text-gray-500">
+          Stage {rules.current_stage} of {stages.length} ({(stages[rules.current_stage - 1].share_bps / 100).toFixed(0)}% of the pot)
+        </span>
+      )}
+    </div>
+  );
+}
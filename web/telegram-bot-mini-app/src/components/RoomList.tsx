@@ -3,6 +3,7 @@ import { Plus, RefreshCw } from 'lucide-react';
 import { RoomCard } from './RoomCard';
 import { Room } from '../types';
 import { apiService } from '../services/api';
+import { formatRake } from '../utils/rake';
 
 interface RoomListProps {
   onJoinRoom: (roomId: string) => void;
@@ -108,6 +109,9 @@ export function RoomList({ onJoinRoom, onViewRoom }: RoomListProps) {
                 <span className="font-bold text-lg text-purple-700">Room #{room.id}</span>
                 <span className="text-sm text-gray-500">Bet: {room.bet_amount} ETB</span>
               </div>
+              <div className="text-xs text-gray-500">
+                House fee: {formatRake(room) ?? 'none'}
+              </div>
               <div className="flex items-center justify-between">
                 <span className="text-gray-700 font-semibold">{room.current_players}/{room.max_players} players</span>
                 {isAlmostFull && <span className="ml-2 px-2 py-1 bg-yellow-200 text-yellow-800 rounded text-xs font-bold animate-pulse">Almost Full</span>}
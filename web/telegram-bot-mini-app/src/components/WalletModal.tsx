@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { X, Plus, Minus, CreditCard, TrendingUp, TrendingDown } from 'lucide-react';
-import { Wallet, Transaction } from '../types';
+import { Wallet, Transaction, PayoutMethod } from '../types';
 import { apiService } from '../services/api';
 
 interface WalletModalProps {
@@ -15,6 +15,10 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
   const [transactions, setTransactions] = useState<Transaction[]>([]);
   const [activeTab, setActiveTab] = useState<'overview' | 'deposit' | 'withdraw' | 'history'>('overview');
   const [amount, setAmount] = useState('');
+  const [mobileNumber, setMobileNumber] = useState('');
+  const [promoCode, setPromoCode] = useState('');
+  const [payoutMethods, setPayoutMethods] = useState<PayoutMethod[]>([]);
+  const [payoutMethodId, setPayoutMethodId] = useState<number | null>(null);
   const [loading, setLoading] = useState(false);
   const [walletError, setWalletError] = useState<any>(null);
 
@@ -26,12 +30,14 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
 
   const loadWalletData = async () => {
     try {
-      const [walletData, transactionData] = await Promise.all([
+      const [walletData, transactionData, methods] = await Promise.all([
         apiService.getWallet(userId),
-        apiService.getTransactions(userId),
+        apiService.getTransactions(),
+        apiService.getPayoutMethods(),
       ]);
       setWallet(walletData);
-      setTransactions(transactionData);
+      setPayoutMethods(methods ?? []);
+      setTransactions(transactionData?.transactions ?? []);
       setWalletError(null);
       onBalanceUpdate(walletData?.balance ?? 0);
     } catch (error) {
@@ -46,13 +52,19 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
     try {
       const depositPayload = {
         amount: parseFloat(amount),
-        currency: 'USD',
+        currency: 'ETB',
         transaction_ref: `webapp-${userId}-${Date.now()}`,
-        status: 'completed',
       };
       console.log('Deposit payload:', depositPayload);
-      console.log('X-User-ID:', userId);
-      await apiService.deposit(userId, depositPayload);
+      const deposit = await apiService.deposit(depositPayload);
+      // The balance is credited once the payment provider confirms the payment
+      if (deposit.checkout_url) {
+        if (window.Telegram?.WebApp?.openLink) {
+          window.Telegram.WebApp.openLink(deposit.checkout_url);
+        } else {
+          window.open(deposit.checkout_url, '_blank');
+        }
+      }
       await loadWalletData();
       setAmount('');
       setActiveTab('overview');
@@ -67,20 +79,21 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
   };
 
   const handleWithdraw = async () => {
-    if (!amount || parseFloat(amount) <= 0 || parseFloat(amount) > (wallet?.balance || 0)) return;
+    if (!amount || parseFloat(amount) <= 0 || parseFloat(amount) > (wallet?.balance || 0) || !payoutMethodId) return;
     
     setLoading(true);
     try {
       const withdrawPayload = {
         amount: parseFloat(amount),
-        currency: 'USD',
-        destination: 'user_wallet',
+        currency: 'ETB',
+        payout_method_id: payoutMethodId,
       };
       console.log('Withdraw payload:', withdrawPayload);
-      await apiService.withdraw(userId, withdrawPayload);
+      const withdrawal = await apiService.withdraw(withdrawPayload);
       await loadWalletData();
       setAmount('');
       setActiveTab('overview');
+      alert(`Withdrawal request #${withdrawal.id} is pending review. The amount is held from your balance until it is paid out.`);
     } catch (error) {
       console.error('Withdraw failed:', error);
       // Show error to user
@@ -91,6 +104,47 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
     }
   };
 
+  // New payout methods can be withdrawn to once an operator has verified them
+  const handleAddPayoutMethod = async () => {
+    if (!mobileNumber) return;
+
+    setLoading(true);
+    try {
+      await apiService.addPayoutMethod({ method_type: 'mobile_money', account: mobileNumber });
+      setMobileNumber('');
+      setPayoutMethods(await apiService.getPayoutMethods());
+      alert('Payout method saved. You can withdraw to it once it has been verified.');
+    } catch (error) {
+      const errorMessage = error instanceof Error ? error.message : 'Could not save the payout method.';
+      alert(`Could not save the payout method: ${errorMessage}`);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleRedeemPromo = async () => {
+    if (!promoCode.trim()) return;
+
+    setLoading(true);
+    try {
+      const redemption = await apiService.redeemPromo(promoCode.trim());
+      setPromoCode('');
+      await loadWalletData();
+      if (redemption.status === 'pending_deposit') {
+        alert('Promo code applied! Your bonus is credited with your next deposit.');
+      } else if (redemption.status === 'completed') {
+        alert(`Promo code applied! ${redemption.bonus_amount.toFixed(2)} has been added to your balance.`);
+      } else {
+        alert(`Promo code applied! ${redemption.bonus_amount.toFixed(2)} bonus credited. Bet ${redemption.wagering_required.toFixed(2)} to make it withdrawable.`);
+      }
+    } catch (error) {
+      const errorMessage = error instanceof Error ? error.message : 'Could not redeem the promo code.';
+      alert(`Could not redeem the promo code: ${errorMessage}`);
+    } finally {
+      setLoading(false);
+    }
+  };
+
   const getTransactionIcon = (type: string) => {
     switch (type) {
       case 'deposit':
@@ -180,11 +234,33 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
                         ? wallet.balance.toFixed(2)
                         : '0.00'}
                     </p>
+                    {!!wallet?.bonus_balance && (
+                      <p className="text-purple-100 text-sm mt-1">
+                        Bonus: ${wallet.bonus_balance.toFixed(2)} (used first for bets)
+                      </p>
+                    )}
                   </div>
                   <CreditCard className="h-8 w-8 text-purple-200" />
                 </div>
               </div>
 
+              <div className="flex space-x-2">
+                <input
+                  type="text"
+                  value={promoCode}
+                  onChange={(e) => setPromoCode(e.target.value)}
+                  className="flex-1 px-3 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-purple-500 focus:border-purple-500"
+                  placeholder="Promo code"
+                />
+                <button
+                  onClick={handleRedeemPromo}
+                  disabled={!promoCode.trim() || loading}
+                  className="px-4 py-2 rounded-lg font-medium text-white bg-purple-600 hover:bg-purple-700 disabled:bg-gray-300 disabled:cursor-not-allowed transition-colors duration-200"
+                >
+                  Redeem
+                </button>
+              </div>
+
               <div className="grid grid-cols-2 gap-4">
                 <button
                   onClick={() => setActiveTab('deposit')}
@@ -324,16 +400,56 @@ export function WalletModal({ isOpen, onClose, onBalanceUpdate, userId }: Wallet
                     />
                   </div>
                   
+                  <div>
+                    <label className="block text-sm font-medium text-gray-700 mb-2">
+                      Send to
+                    </label>
+                    <select
+                      value={payoutMethodId ?? ''}
+                      onChange={(e) => setPayoutMethodId(e.target.value ? Number(e.target.value) : null)}
+                      className="w-full px-3 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-purple-500 focus:border-purple-500"
+                    >
+                      <option value="">Choose a payout method</option>
+                      {payoutMethods.map((method) => (
+                        <option key={method.id} value={method.id} disabled={!method.verified_at}>
+                          {method.label || method.account}{method.verified_at ? '' : ' (awaiting verification)'}
+                        </option>
+                      ))}
+                    </select>
+                  </div>
+
+                  <div>
+                    <label className="block text-sm font-medium text-gray-700 mb-2">
+                      Add mobile money number
+                    </label>
+                    <div className="flex space-x-2">
+                      <input
+                        type="tel"
+                        value={mobileNumber}
+                        onChange={(e) => setMobileNumber(e.target.value)}
+                        className="flex-1 px-3 py-2 border border-gray-300 rounded-lg focus:ring-2 focus:ring-purple-500 focus:border-purple-500"
+                        placeholder="0911223344"
+                      />
+                      <button
+                        onClick={handleAddPayoutMethod}
+                        disabled={!mobileNumber || loading}
+                        className="px-4 py-2 rounded-lg font-medium bg-purple-500 text-white disabled:bg-gray-300 disabled:text-gray-500"
+                      >
+                        Add
+                      </button>
+                    </div>
+                  </div>
+
                   <p className="text-sm text-gray-600">
                     Available balance: {(wallet && wallet.balance !== undefined && wallet.balance !== null) ? wallet.balance.toFixed(2) : '0.00'}
                   </p>
                   
                   <button
                     onClick={handleWithdraw}
-                    disabled={!amount || parseFloat(amount) <= 0 || parseFloat(amount) > (wallet?.balance || 0) || loading}
+                    disabled={!amount || parseFloat(amount) <= 0 || parseFloat(amount) > (wallet?.balance || 0) || !payoutMethodId || loading}
                     className={`
                       w-full py-3 px-4 rounded-lg font-medium transition-all duration-200
-                      ${amount && parseFloat(amount) > 0 && parseFloat(amount) <= (wallet?.balance || 0) && !loading
+                      ${amount && parseFloat(amount) > 0 && parseFloat(amount) <= (wallet?.balance || 0) && payoutMethodId && !loading
                         ? 'bg-gradient-to-r from-red-500 to-pink-500 text-white hover:from-red-600 hover:to-pink-600'
                         : 'bg-gray-300 text-gray-500 cursor-not-allowed'
                       }
@@ -28,17 +28,12 @@ export function useTelegram() {
 
   // Authenticate user by sending parsed user data to backend
   useEffect(() => {
-    if (!user) return;
+    if (!user || !webApp) return;
 
     fetch('http://localhost:3000/api/auth/telegram', {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({
-        telegram_id: user.id,
-        username: user.username || '',
-        first_name: user.first_name,
-        last_name: user.last_name || '',
-      }),
+      body: JSON.stringify({ init_data: webApp.initData }),
     })
       .then((res) => {
         if (!res.ok) throw new Error(`HTTP error ${res.status}`);
@@ -46,7 +41,7 @@ export function useTelegram() {
       })
       .then((data) => setAuthResponse(data))
       .catch((err) => setAuthError(err.message || 'Failed to authenticate'));
-  }, [user]);
+  }, [user, webApp]);
 
   return { user, webApp, authResponse, authError };
 }
\ No newline at end of file
@@ -1,18 +1,19 @@
-import { Room, BingoCard, GameSession, Wallet, Transaction, Player, User } from '../types';
+import { Room, RoomRules, CardVariant, WinPattern, BingoCard, GameSession, Wallet, TransactionPage, TransactionQuery, PromoRedemption, BonusSummary, Player, User, AuthTokens, WithdrawalRequest, PaymentDeposit, PayoutMethod, PayoutMethodType } from '../types';
 
 const API_BASE_URL = 'http://localhost:3000/api';
 
 class ApiService {
   private user: User | null = null;
+  private tokens: AuthTokens | null = null;
 
-  private async request(endpoint: string, options: RequestInit = {}) {
-    const headers = {
+  private async request(endpoint: string, options: RequestInit = {}, retried = false): Promise<any> {
+    const headers: Record<string, string> = {
       'Content-Type': 'application/json',
-      ...options.headers,
+      ...(options.headers as Record<string, string>),
     };
 
-    if (this.user) {
-      headers['X-User-ID'] = this.user.id.toString();
+    if (this.tokens) {
+      headers['Authorization'] = `Bearer ${this.tokens.access_token}`;
     }
 
     const response = await fetch(`${API_BASE_URL}${endpoint}`, {
@@ -20,6 +21,11 @@ class ApiService {
       headers,
     });
 
+    // Access tokens are short-lived; refresh once and replay the request
+    if (response.status === 401 && this.tokens && !retried && await this.refreshTokens()) {
+      return this.request(endpoint, options, true);
+    }
+
     if (!response.ok) {
       // For 404 errors on GET requests, return null instead of throwing
       if (response.status === 404 && (options.method === 'GET' || !options.method)) {
@@ -60,15 +66,38 @@ class ApiService {
     this.user = user;
   }
 
-  async authenticateTelegram(data: any) {
-    const user = await this.request('/auth/telegram', {
+  // Authenticates with the signed Telegram WebApp initData and keeps the
+  // returned bearer tokens for every later request.
+  async authenticateTelegram(initData: string) {
+    const auth = await this.request('/auth/telegram', {
       method: 'POST',
-      body: JSON.stringify(data),
+      body: JSON.stringify({ init_data: initData }),
+    });
+    if (auth) {
+      this.tokens = auth.tokens;
+      this.setAuthUser(auth.user);
+    }
+    return auth?.user ?? null;
+  }
+
+  private async refreshTokens(): Promise<boolean> {
+    const response = await fetch(`${API_BASE_URL}/auth/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: this.tokens?.refresh_token }),
     });
-    if (user) {
-      this.setAuthUser(user);
+    if (!response.ok) {
+      this.tokens = null;
+      return false;
     }
-    return user;
+    this.tokens = await response.json();
+    return true;
+  }
+
+  async logout() {
+    await this.request('/auth/logout', { method: 'POST' });
+    this.tokens = null;
+    this.user = null;
   }
 
   // Profile
@@ -85,7 +114,7 @@ class ApiService {
 
   // Rooms
   async createRoom(data: any): Promise<Room> {
-    return this.request('/rooms', {
+    return this.request('/admin/rooms', {
       method: 'POST',
       body: JSON.stringify(data),
     });
@@ -99,10 +128,10 @@ class ApiService {
     return this.request(`/rooms/${id}`);
   }
 
-  async findOrCreateRoom(betAmount: number): Promise<Room> {
+  async findOrCreateRoom(betAmount: number, variant: CardVariant = '75_ball'): Promise<Room> {
     return this.request('/rooms/find-or-create', {
       method: 'POST',
-      body: JSON.stringify({ bet_amount: betAmount }),
+      body: JSON.stringify({ bet_amount: betAmount, variant }),
     });
   }
 
@@ -119,11 +148,19 @@ class ApiService {
   }
 
   async startRoom(id: string) {
-    return this.request(`/rooms/${id}/start`, { 
+    return this.request(`/admin/rooms/${id}/start`, { 
       method: 'POST',
     });
   }
 
+  async getRoomRules(id: string): Promise<RoomRules> {
+    return this.request(`/rooms/${id}/rules`);
+  }
+
+  async getPatterns(): Promise<WinPattern[]> {
+    return this.request('/patterns');
+  }
+
   async getRoomPlayers(id: string): Promise<Player[]> {
     return this.request(`/rooms/${id}/players`);
   }
@@ -143,6 +180,7 @@ class ApiService {
   async selectCard(roomId: string, cardNumber: number): Promise<void> {
     return this.request(`/rooms/${roomId}/select-card`, {
       method: 'POST',
+      headers: { 'Idempotency-Key': crypto.randomUUID() },
       body: JSON.stringify({ card_number: cardNumber }),
     });
   }
@@ -159,38 +197,11 @@ class ApiService {
     }
   }
 
-  // Cards
-  async createCard(roomId: string): Promise<BingoCard> {
-    return this.request('/cards', {
-      method: 'POST',
-      body: JSON.stringify({ room_id: roomId }),
-    });
-  }
-
   // Session
-  async createSession(roomId: string): Promise<GameSession> {
-    return this.request('/sessions', {
-      method: 'POST',
-      body: JSON.stringify({ room_id: roomId }),
-    });
-  }
-
   async getSession(id: string): Promise<GameSession> {
     return this.request(`/sessions/${id}`);
   }
 
-  async drawNumber(id: string): Promise<{ number: number }> {
-    return this.request(`/sessions/${id}/draw`, { 
-      method: 'POST',
-    });
-  }
-
-  async autoDrawNumber(id: string): Promise<{ number: number }> {
-    return this.request(`/sessions/${id}/auto-draw`, { 
-      method: 'POST',
-    });
-  }
-
   async markNumber(sessionId: string, cardNumber: number, number: number): Promise<void> {
     return this.request(`/sessions/${sessionId}/mark`, {
       method: 'POST',
@@ -201,6 +212,7 @@ class ApiService {
   async claimBingo(sessionId: string, cardNumber: number): Promise<void> {
     return this.request(`/sessions/${sessionId}/bingo`, {
       method: 'POST',
+      headers: { 'Idempotency-Key': crypto.randomUUID() },
       body: JSON.stringify({ card_number: cardNumber }),
     });
   }
@@ -214,19 +226,53 @@ class ApiService {
     return this.request('/wallet');
   }
 
-  async getTransactions(): Promise<Transaction[]> {
-    return this.request('/transactions');
+  async getTransactions(query: TransactionQuery = {}): Promise<TransactionPage> {
+    const params = new URLSearchParams();
+    Object.entries(query).forEach(([key, value]) => {
+      if (value === undefined || value === null) return;
+      params.set(key, Array.isArray(value) ? value.join(',') : String(value));
+    });
+    const qs = params.toString();
+    return this.request(`/transactions${qs ? `?${qs}` : ''}`);
   }
 
-  async deposit(data: any) {
+  async deposit(data: any): Promise<PaymentDeposit> {
     return this.request('/deposit', {
       method: 'POST',
+      headers: { 'Idempotency-Key': crypto.randomUUID() },
       body: JSON.stringify(data),
     });
   }
 
-  async withdraw(data: any) {
+  async withdraw(data: any): Promise<WithdrawalRequest> {
     return this.request('/withdraw', {
+      method: 'POST',
+      headers: { 'Idempotency-Key': crypto.randomUUID() },
+      body: JSON.stringify(data),
+    });
+  }
+
+  async redeemPromo(code: string): Promise<PromoRedemption> {
+    return this.request('/promo/redeem', {
+      method: 'POST',
+      body: JSON.stringify({ code }),
+    });
+  }
+
+  async getBonus(): Promise<BonusSummary> {
+    return this.request('/bonus');
+  }
+
+  async getWithdrawals(): Promise<WithdrawalRequest[]> {
+    return this.request('/withdrawals');
+  }
+
+  async getPayoutMethods(): Promise<PayoutMethod[]> {
+    return this.request('/payout-methods');
+  }
+
+  async addPayoutMethod(data: { method_type: PayoutMethodType; account: string; bank_code?: string; label?: string }): Promise<PayoutMethod> {
+    return this.request('/payout-methods', {
       method: 'POST',
       body: JSON.stringify(data),
     });
@@ -18,6 +18,7 @@ export interface TelegramWebApp {
     [key: string]: any;
   };
   close: () => void;
+  openLink?: (url: string) => void;
   sendData: (data: string) => void;
   expand: () => void;
   isExpanded: boolean;
@@ -34,24 +35,82 @@ export interface User {
   created_at: string;
 }
 
+export interface AuthTokens {
+  access_token: string;
+  refresh_token: string;
+  token_type: string;
+  expires_at: string;
+}
+
 export interface Room {
   id: string;
   bet_amount: number;
   current_players: number;
   max_players: number;
   status: 'waiting' | 'active' | 'completed';
+  no_winner_policy?: NoWinnerPolicy;
+  rake_bps?: number;
+  rake_min?: number;
+  rake_cap?: number | null;
+  draw_interval_seconds?: number;
+  completed_at?: string | null;
+  recycled_at?: string | null;
+  win_pattern?: string;
+  variant?: CardVariant;
   created_at: string;
   updated_at: string;
 }
 
+// 75-ball rooms play 5x5 cards, 90-ball rooms 3x9 tickets dealt in strips of six
+export type CardVariant = '75_ball' | '90_ball';
+
+// Grid and marks of a card; empty cells and the free space hold 0. Cards
+// without a variant are 75-ball.
+export interface CardData {
+  variant?: CardVariant;
+  strip?: number;
+  grid: number[][];
+  marks: boolean[][];
+}
+
+// A card wins when it covers every cell of any one of the masks
+export interface WinPattern {
+  name: string;
+  variant: CardVariant;
+  masks: boolean[][][];
+  built_in: boolean;
+}
+
+export interface PrizeStage {
+  stage: number;
+  pattern: WinPattern;
+  share_bps: number;
+}
+
+export interface RoomRules {
+  room_id: string;
+  variant: CardVariant;
+  session_id: string | null;
+  bet_amount: number;
+  win_pattern: WinPattern;
+  prize_stages: PrizeStage[];
+  current_stage: number;
+  draw_interval_seconds: number;
+  claim_window_seconds: number;
+  rake_bps: number;
+  rake_min: number;
+  rake_cap: number | null;
+}
+
+export type NoWinnerPolicy = 'refund' | 'rollover' | 'jackpot';
+
+export type SessionOutcome = 'won' | 'refunded' | 'rolled_over' | 'jackpot';
+
 export interface BingoCard {
   id: string;
   user_id: string;
   room_id: string;
-  card_data: {
-    grid: number[][];
-    marks: boolean[][];
-  };
+  card_data: CardData;
   is_winner: boolean;
   created_at: string;
 }
@@ -64,6 +123,15 @@ export interface GameSession {
   status: 'active' | 'completed';
   drawn_numbers: number[];
   remaining_numbers: number[];
+  carried_pot: number;
+  outcome: SessionOutcome | null;
+  outcome_amount: number | null;
+  claim_deadline: string | null;
+  next_draw_at: string | null;
+  win_pattern: WinPattern | null;
+  prize_stages: PrizeStage[] | null;
+  current_stage: number;
+  pot: number;
   created_at: string;
 }
 
@@ -71,15 +139,108 @@ export interface Wallet {
   id: string;
   user_id: string;
   balance: number;
+  bonus_balance?: number;
+  frozen_at?: string | null;
+  frozen_reason?: string | null;
   created_at: string;
   updated_at: string;
 }
 
+export type TransactionType =
+  | 'deposit'
+  | 'withdraw'
+  | 'bet'
+  | 'win'
+  | 'refund'
+  | 'rollover'
+  | 'jackpot'
+  | 'withdraw_return'
+  | 'bonus'
+  | 'bonus_release'
+  | 'referral';
+
 export interface Transaction {
   id: string;
   user_id: string;
-  type: 'deposit' | 'withdraw' | 'bet' | 'win';
+  type: TransactionType;
+  balance: 'cash' | 'bonus';
+  direction: 'credit' | 'debit' | 'none';
+  amount: number;
+  room_id?: number;
+  session_id?: number;
+  deposit_id?: number;
+  withdrawal_id?: number;
+  created_at: string;
+}
+
+export type PromoRedemptionStatus = 'pending_deposit' | 'active' | 'completed' | 'forfeited';
+
+export interface PromoRedemption {
+  id: number;
+  promo_code_id: number;
+  code?: string;
+  status: PromoRedemptionStatus;
+  bonus_amount: number;
+  wagering_required: number;
+  wagered: number;
+  created_at: string;
+}
+
+export interface BonusSummary {
+  bonus_balance: number;
+  redemptions: PromoRedemption[];
+}
+
+export interface TransactionPage {
+  transactions: Transaction[];
+  next_cursor: number | null;
+}
+
+export interface TransactionQuery {
+  type?: TransactionType[];
+  from?: string;
+  to?: string;
+  room_id?: number;
+  session_id?: number;
+  cursor?: number;
+  limit?: number;
+}
+
+export type WithdrawalStatus = 'pending' | 'approved' | 'rejected' | 'processing' | 'paid' | 'failed';
+
+export interface WithdrawalRequest {
+  id: number;
+  user_id: number;
+  amount: number;
+  currency: string;
+  destination: string;
+  payout_method_id?: number | null;
+  status: WithdrawalStatus;
+  note?: string | null;
+  reviewed_at?: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export type PayoutMethodType = 'mobile_money' | 'bank_account';
+
+export interface PayoutMethod {
+  id: number;
+  method_type: PayoutMethodType;
+  account: string;
+  bank_code: string;
+  label?: string | null;
+  verified_at?: string | null;
+  created_at: string;
+}
+
+export interface PaymentDeposit {
+  id: number;
   amount: number;
+  currency: string;
+  transaction_ref: string;
+  status: 'pending' | 'completed' | 'failed';
+  checkout_url?: string | null;
   created_at: string;
 }
 
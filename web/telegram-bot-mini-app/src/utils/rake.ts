@@ -0,0 +1,25 @@
+import { Room } from '../types';
+
+type RakeSettings = Pick<Room, 'rake_bps' | 'rake_min' | 'rake_cap'>;
+
+// House commission on a pot, mirroring db.RakeConfig.Rake on the server
+export function rakeFor(room: RakeSettings, pot: number): number {
+  const potCents = Math.round(pot * 100);
+  let rake = Math.trunc((potCents * (room.rake_bps ?? 0)) / 10000);
+  rake = Math.max(rake, Math.round((room.rake_min ?? 0) * 100));
+  if (room.rake_cap != null) rake = Math.min(rake, Math.round(room.rake_cap * 100));
+  return Math.max(0, Math.min(rake, potCents)) / 100;
+}
+
+// Describes the house commission, e.g. "5% (min 1.00, max 50.00 ETB)"
+export function formatRake(room: RakeSettings): string | null {
+  const bps = room.rake_bps ?? 0;
+  const min = room.rake_min ?? 0;
+  if (bps === 0 && min === 0) return null;
+  const limits = [
+    min > 0 ? `min ${min.toFixed(2)}` : null,
+    room.rake_cap != null ? `max ${room.rake_cap.toFixed(2)}` : null,
+  ].filter(Boolean);
+  const percent = `${(bps / 100).toString()}%`;
+  return limits.length > 0 ? `${percent} (${limits.join(', ')} ETB)` : percent;
+}